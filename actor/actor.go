@@ -0,0 +1,446 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 轻量 Actor 框架：每个 actor 是一个协程加一个邮箱通道，状态只在自己的协程里修改，
+// 其他组件只能通过地址发送消息（Send）或请求/应答（Request），不直接共享内存。
+// 这正是 four_channel 里"通过通信来共享内存"的思路：邮箱就是带缓冲的通道，
+// 应答通过随请求一起发送的单向通道（chan<-）返回。
+//
+// 监督者（Supervisor）负责启动子 actor，子 actor 崩溃（Receive 返回错误或 panic）时按策略重启：
+//   - OneForOne：只重启崩溃的子 actor
+//   - OneForAll：停止并重启所有子 actor
+// 如果在 Period 时间内重启次数超过 MaxRestarts，监督者放弃重启并停止所有子 actor。
+// 监督者本身也可以作为子节点（见 SupervisorChild）组成监督树：子监督者放弃时，
+// 它的错误作为一次崩溃上报给上级监督者，由上级按自己的策略重启整棵子树或继续向上报告。
+// 处理请求时崩溃、或 actor 停止时仍未处理的请求，Request 会立即返回错误而不是等到 ctx 超时。
+
+// ErrNotFound 地址没有对应的 actor
+var ErrNotFound = errors.New("actor: 地址不存在")
+
+// ErrStopped actor 已停止，邮箱不再接收消息
+var ErrStopped = errors.New("actor: 已停止")
+
+// ErrCrashed actor 处理请求时崩溃，没有应答
+var ErrCrashed = errors.New("actor: 处理请求时崩溃")
+
+// Address actor 的地址
+type Address string
+
+// Context 消息处理时可用的上下文
+type Context struct {
+	Self    Address
+	System  *System
+	replyTo chan<- reply
+}
+
+// reply 请求的应答，err 不为 nil 表示 actor 没能应答
+type reply struct {
+	v   any
+	err error
+}
+
+// Reply 回复当前的请求；消息不是通过 Request 发送的则什么也不做
+func (c *Context) Reply(v any) {
+	c.respond(reply{v: v})
+}
+
+func (c *Context) respond(r reply) {
+	if c.replyTo != nil {
+		c.replyTo <- r // 有 1 个缓冲，不会阻塞
+		c.replyTo = nil
+	}
+}
+
+// Actor 消息处理者。Receive 在 actor 自己的协程中串行调用，返回错误表示崩溃
+type Actor interface {
+	Receive(ctx *Context, msg any) error
+}
+
+// Props 创建 actor 的工厂函数，重启时会重新调用以得到全新的状态
+type Props func() Actor
+
+// envelope 邮箱中的一条消息
+type envelope struct {
+	msg     any
+	replyTo chan<- reply
+}
+
+// System actor 系统：按地址登记邮箱，负责消息路由
+type System struct {
+	mu      sync.RWMutex
+	mailbox map[Address]*cell
+}
+
+// NewSystem 创建 actor 系统
+func NewSystem() *System {
+	return &System{mailbox: make(map[Address]*cell)}
+}
+
+// Send 向地址发送消息，不等待处理；邮箱满时阻塞
+func (s *System) Send(to Address, msg any) error {
+	c, err := s.lookup(to)
+	if err != nil {
+		return err
+	}
+	return c.post(envelope{msg: msg})
+}
+
+// Request 向地址发送消息并等待 ctx.Reply 的应答。
+// actor 处理该请求时崩溃返回 ErrCrashed，请求处理前 actor 被停止返回 ErrStopped
+func (s *System) Request(ctx context.Context, to Address, msg any) (any, error) {
+	c, err := s.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan reply, 1)
+	if err := c.post(envelope{msg: msg, replyTo: ch}); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-ch:
+		return r.v, r.err
+	case <-c.stop:
+		// 停止前可能刚好应答了
+		select {
+		case r := <-ch:
+			return r.v, r.err
+		default:
+			return nil, ErrStopped
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *System) lookup(addr Address) (*cell, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.mailbox[addr]
+	if !ok {
+		return nil, fmt.Errorf("%w：%s", ErrNotFound, addr)
+	}
+	return c, nil
+}
+
+func (s *System) register(addr Address, c *cell) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mailbox[addr]; ok {
+		return fmt.Errorf("actor: 地址 %s 已被占用", addr)
+	}
+	s.mailbox[addr] = c
+	return nil
+}
+
+func (s *System) unregister(addr Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.mailbox, addr)
+}
+
+// cell 一个 actor 的运行实体。邮箱在重启之间保留，崩溃时未处理的消息不会丢失
+type cell struct {
+	addr    Address
+	props   Props
+	mailbox chan envelope
+	stop    chan struct{} // 关闭表示停止，post 不再投递
+}
+
+func (c *cell) post(e envelope) error {
+	select {
+	case <-c.stop:
+		return ErrStopped
+	default:
+	}
+	select {
+	case c.mailbox <- e:
+		return nil
+	case <-c.stop:
+		return ErrStopped
+	}
+}
+
+// run 处理消息直到崩溃或停止；返回 nil 表示正常停止。
+// 崩溃时如果正在处理的请求还没有应答，以 ErrCrashed 应答它
+func (c *cell) run(sys *System, halt <-chan struct{}) (err error) {
+	var current *Context
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("actor %s panic: %v", c.addr, r)
+		}
+		if err != nil && current != nil {
+			current.respond(reply{err: fmt.Errorf("%w：%w", ErrCrashed, err)})
+		}
+	}()
+	a := c.props()
+
+	for {
+		select {
+		case e := <-c.mailbox:
+			current = &Context{Self: c.addr, System: sys, replyTo: e.replyTo}
+			if err := a.Receive(current, e.msg); err != nil {
+				return fmt.Errorf("actor %s: %w", c.addr, err)
+			}
+			current = nil
+		case <-halt:
+			return nil
+		}
+	}
+}
+
+// close 停止接收消息，等待应答的 Request 随之返回 ErrStopped
+func (c *cell) close(sys *System) {
+	close(c.stop)
+	sys.unregister(c.addr)
+}
+
+// Strategy 监督策略
+type Strategy int
+
+const (
+	OneForOne Strategy = iota // 只重启崩溃的子节点
+	OneForAll                 // 任一子节点崩溃时重启全部子节点
+)
+
+// Child 子节点的规格：一个 actor，或由 SupervisorChild 创建的子监督者
+type Child struct {
+	Addr    Address
+	Props   Props
+	Mailbox int // 邮箱缓冲大小
+
+	sup      *Supervisor // 子监督者，nil 表示这是一个 actor
+	children []Child
+}
+
+// SupervisorChild 把 sup 作为子节点：上级启动它时，它启动并监督 children；
+// 它放弃重启时，错误作为 addr 的一次崩溃上报给上级。sup.System 为 nil 时使用上级的 System。
+// addr 只用于标识（例如 OnCrash），不能接收消息。
+func SupervisorChild(addr Address, sup *Supervisor, children ...Child) Child {
+	return Child{Addr: addr, sup: sup, children: children}
+}
+
+// node 监督者的一个子节点
+type node interface {
+	address() Address
+	// run 运行子节点直到 halt 关闭（返回 nil）或崩溃（返回错误）
+	run(sys *System, halt <-chan struct{}) error
+	// close 监督者退出时调用，之后不会再 run
+	close(sys *System)
+}
+
+func (c *cell) address() Address { return c.addr }
+
+// nested 作为子节点的监督者，每次 run 都重新启动整棵子树
+type nested struct {
+	addr     Address
+	sup      *Supervisor
+	children []Child
+}
+
+func (n *nested) address() Address { return n.addr }
+
+func (n *nested) run(sys *System, halt <-chan struct{}) error {
+	if err := n.sup.Start(n.children...); err != nil {
+		return err
+	}
+	select {
+	case <-halt:
+		n.sup.Stop()
+		return nil
+	case <-n.sup.Done(): // 子监督者放弃了，向上报告
+		return n.sup.Stop()
+	}
+}
+
+func (n *nested) close(*System) {}
+
+// Supervisor 监督者。MaxRestarts 为 0 时子节点第一次崩溃就放弃；
+// MaxRestarts 大于 0 时 Period 必须大于 0，否则 Start 返回错误
+type Supervisor struct {
+	System      *System
+	Strategy    Strategy
+	MaxRestarts int           // Period 内最多重启次数，超过后放弃
+	Period      time.Duration // 统计重启次数的时间窗口
+	OnCrash     func(addr Address, err error)
+
+	nodes    []node
+	restarts []time.Time
+	crashes  chan crash
+	done     chan struct{} // Stop 时关闭
+	stopOnce sync.Once
+	exited   chan struct{} // 监督协程退出后关闭
+	err      error
+}
+
+type crash struct {
+	index int
+	gen   int // 崩溃时的代数，OneForAll 重启后旧代的崩溃报告会被忽略
+	err   error
+}
+
+// Start 登记并启动所有子节点，监督协程在后台运行。
+// 监督者退出（Stop 或放弃）后可以再次 Start，作为子监督者被重启时就是这样
+func (s *Supervisor) Start(children ...Child) error {
+	if s.MaxRestarts > 0 && s.Period <= 0 {
+		return fmt.Errorf("actor: MaxRestarts 为 %d 时 Period 必须大于 0", s.MaxRestarts)
+	}
+	if s.System == nil {
+		return errors.New("actor: Supervisor.System 为 nil")
+	}
+
+	s.nodes, s.restarts, s.err = nil, nil, nil
+	s.stopOnce = sync.Once{}
+	s.crashes = make(chan crash)
+	s.done = make(chan struct{})
+	s.exited = make(chan struct{})
+	for _, ch := range children {
+		if ch.sup != nil {
+			if ch.sup.System == nil {
+				ch.sup.System = s.System
+			}
+			s.nodes = append(s.nodes, &nested{addr: ch.Addr, sup: ch.sup, children: ch.children})
+			continue
+		}
+		c := &cell{
+			addr:    ch.Addr,
+			props:   ch.Props,
+			mailbox: make(chan envelope, ch.Mailbox),
+			stop:    make(chan struct{}),
+		}
+		if err := s.System.register(ch.Addr, c); err != nil {
+			for _, started := range s.nodes {
+				if c, ok := started.(*cell); ok {
+					s.System.unregister(c.addr)
+				}
+			}
+			return err
+		}
+		s.nodes = append(s.nodes, c)
+	}
+	go s.supervise()
+	return nil
+}
+
+// Stop 停止所有子节点并注销地址，返回监督者放弃重启时的错误
+func (s *Supervisor) Stop() error {
+	s.stopOnce.Do(func() { close(s.done) })
+	<-s.exited
+	return s.err
+}
+
+// Done 返回一个通道，监督者放弃重启或被 Stop 后关闭，之后 Err 返回放弃的原因
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.exited
+}
+
+// Err 返回监督者放弃重启的原因；仍在运行或被 Stop 正常停止时为 nil
+func (s *Supervisor) Err() error {
+	select {
+	case <-s.exited:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// supervise 监督循环：启动子节点，收到崩溃报告时按策略重启
+func (s *Supervisor) supervise() {
+	gen := 0
+	halts := make([]chan struct{}, len(s.nodes))
+	var wg sync.WaitGroup
+
+	start := func(i int) {
+		halts[i] = make(chan struct{})
+		wg.Add(1)
+		go func(n node, halt chan struct{}, gen int) {
+			defer wg.Done()
+			if err := n.run(s.System, halt); err != nil {
+				// 监督者正在停止这个子节点时（OneForAll 重启、放弃、Stop），崩溃报告可以丢弃
+				select {
+				case s.crashes <- crash{index: i, gen: gen, err: err}:
+				case <-halt:
+				case <-s.done:
+				}
+			}
+		}(s.nodes[i], halts[i], gen)
+	}
+	haltAll := func() {
+		for _, h := range halts {
+			close(h)
+		}
+		wg.Wait()
+	}
+
+	for i := range s.nodes {
+		start(i)
+	}
+
+	// 先关闭子节点（等待中的请求随之失败），再通知 Stop 和上级
+	defer close(s.exited)
+	defer func() {
+		for _, n := range s.nodes {
+			n.close(s.System)
+		}
+	}()
+
+	for {
+		select {
+		case <-s.done:
+			haltAll()
+			return
+		case cr := <-s.crashes:
+			if cr.gen != gen {
+				continue
+			}
+			if s.OnCrash != nil {
+				s.OnCrash(s.nodes[cr.index].address(), cr.err)
+			}
+			if !s.allowRestart() {
+				s.err = fmt.Errorf("actor: %v 内重启超过 %d 次，放弃：%w", s.Period, s.MaxRestarts, cr.err)
+				haltAllExcept(halts, cr.index)
+				wg.Wait()
+				return
+			}
+			switch s.Strategy {
+			case OneForOne:
+				start(cr.index)
+			case OneForAll:
+				haltAllExcept(halts, cr.index)
+				wg.Wait()
+				gen++
+				for i := range s.nodes {
+					start(i)
+				}
+			}
+		}
+	}
+}
+
+// haltAllExcept 停止除已崩溃的子节点以外的所有子节点
+func haltAllExcept(halts []chan struct{}, crashed int) {
+	for i, h := range halts {
+		if i != crashed {
+			close(h)
+		}
+	}
+}
+
+// allowRestart 记录一次重启，判断是否仍在重启强度限制内
+func (s *Supervisor) allowRestart() bool {
+	now := time.Now()
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.Period {
+			recent = append(recent, t)
+		}
+	}
+	s.restarts = append(recent, now)
+	return len(s.restarts) <= s.MaxRestarts
+}
@@ -0,0 +1,268 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// counter 测试用 actor："inc" 加一，"get" 应答当前值，"crash" 返回错误，"panic" panic，
+// chan struct{} 阻塞到通道关闭
+type counter struct{ n int }
+
+var errCrash = errors.New("crash")
+
+func (c *counter) Receive(ctx *Context, msg any) error {
+	switch m := msg.(type) {
+	case string:
+		switch m {
+		case "inc":
+			c.n++
+		case "get":
+			ctx.Reply(c.n)
+		case "crash":
+			return errCrash
+		case "panic":
+			panic("boom")
+		}
+	case chan struct{}:
+		<-m
+	}
+	return nil
+}
+
+func counterChild(addr Address) Child {
+	return Child{Addr: addr, Props: func() Actor { return &counter{} }, Mailbox: 16}
+}
+
+func get(t *testing.T, sys *System, addr Address) int {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := sys.Request(ctx, addr, "get")
+	if err != nil {
+		t.Fatalf("Request(%s, get) = %v", addr, err)
+	}
+	return v.(int)
+}
+
+// crashes 记录 OnCrash 的调用
+type crashes struct {
+	mu    sync.Mutex
+	addrs []Address
+}
+
+func (c *crashes) record(addr Address, _ error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addrs = append(c.addrs, addr)
+}
+
+func (c *crashes) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.addrs)
+}
+
+func TestRequestReply(t *testing.T) {
+	sys := NewSystem()
+	sup := &Supervisor{System: sys}
+	if err := sup.Start(counterChild("a")); err != nil {
+		t.Fatal(err)
+	}
+	defer sup.Stop()
+
+	for range 3 {
+		sys.Send("a", "inc")
+	}
+	if n := get(t, sys, "a"); n != 3 {
+		t.Errorf("get = %d, want 3", n)
+	}
+	if err := sys.Send("missing", "inc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Send to missing address = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOneForOne(t *testing.T) {
+	sys := NewSystem()
+	var c crashes
+	sup := &Supervisor{System: sys, MaxRestarts: 5, Period: time.Minute, OnCrash: c.record}
+	if err := sup.Start(counterChild("a"), counterChild("b")); err != nil {
+		t.Fatal(err)
+	}
+	defer sup.Stop()
+
+	sys.Send("a", "inc")
+	sys.Send("b", "inc")
+	sys.Send("a", "panic")
+	sys.Send("a", "inc") // 邮箱在重启之间保留，崩溃后的消息由新实例处理
+
+	if n := get(t, sys, "a"); n != 1 {
+		t.Errorf("a = %d, want 1 (restarted with fresh state)", n)
+	}
+	if n := get(t, sys, "b"); n != 1 {
+		t.Errorf("b = %d, want 1 (not restarted)", n)
+	}
+	if c.count() != 1 {
+		t.Errorf("OnCrash called %d times, want 1", c.count())
+	}
+}
+
+func TestOneForAll(t *testing.T) {
+	sys := NewSystem()
+	sup := &Supervisor{System: sys, Strategy: OneForAll, MaxRestarts: 5, Period: time.Minute}
+	if err := sup.Start(counterChild("a"), counterChild("b")); err != nil {
+		t.Fatal(err)
+	}
+	defer sup.Stop()
+
+	sys.Send("b", "inc")
+	get(t, sys, "b") // 确认 b 已处理
+	sys.Send("a", "crash")
+
+	// b 也被重启，状态清零
+	deadline := time.Now().Add(time.Second)
+	for get(t, sys, "b") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("b was not restarted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestFailsOnCrash(t *testing.T) {
+	sys := NewSystem()
+	sup := &Supervisor{System: sys, MaxRestarts: 5, Period: time.Minute}
+	if err := sup.Start(counterChild("a")); err != nil {
+		t.Fatal(err)
+	}
+	defer sup.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	for _, msg := range []string{"crash", "panic"} {
+		if _, err := sys.Request(ctx, "a", msg); !errors.Is(err, ErrCrashed) {
+			t.Errorf("Request(%s) = %v, want ErrCrashed", msg, err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Request waited %v, want an immediate failure", d)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	sys := NewSystem()
+	sup := &Supervisor{System: sys} // MaxRestarts 为 0：第一次崩溃就放弃
+	if err := sup.Start(counterChild("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	// a 先阻塞，让请求排在崩溃消息之后
+	gate := make(chan struct{})
+	sys.Send("a", gate)
+	sys.Send("a", "crash")
+	pending := make(chan error, 1)
+	go func() {
+		_, err := sys.Request(context.Background(), "a", "get")
+		pending <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(gate)
+
+	select {
+	case <-sup.Done():
+	case <-time.After(time.Second):
+		t.Fatal("supervisor did not give up")
+	}
+	if err := sup.Err(); !errors.Is(err, errCrash) {
+		t.Errorf("Err() = %v, want wrapping errCrash", err)
+	}
+	select {
+	case err := <-pending:
+		if !errors.Is(err, ErrStopped) {
+			t.Errorf("pending Request = %v, want ErrStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending Request still waiting after the supervisor gave up")
+	}
+	if err := sys.Send("a", "inc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Send after give-up = %v, want ErrNotFound", err)
+	}
+	if err := sup.Stop(); !errors.Is(err, errCrash) {
+		t.Errorf("Stop() = %v, want wrapping errCrash", err)
+	}
+}
+
+func TestPeriodRequired(t *testing.T) {
+	sup := &Supervisor{System: NewSystem(), MaxRestarts: 3}
+	if err := sup.Start(counterChild("a")); err == nil {
+		sup.Stop()
+		t.Error("Start with MaxRestarts > 0 and Period 0: want error")
+	}
+}
+
+func TestNestedSupervisor(t *testing.T) {
+	sys := NewSystem()
+	var c crashes
+	inner := &Supervisor{MaxRestarts: 0} // 第一次崩溃就放弃，交给上级
+	root := &Supervisor{System: sys, MaxRestarts: 1, Period: time.Minute, OnCrash: c.record}
+	if err := root.Start(
+		SupervisorChild("inner", inner, counterChild("leaf")),
+		counterChild("sibling"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	defer root.Stop()
+
+	waitLeaf := func() {
+		deadline := time.Now().Add(time.Second)
+		for {
+			if _, err := sys.lookup("leaf"); err == nil {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("leaf not registered")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitLeaf()
+	sys.Send("leaf", "inc")
+	sys.Send("leaf", "crash")
+
+	// inner 放弃后上报给 root，root 重启整棵子树
+	deadline := time.Now().Add(time.Second)
+	for c.count() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("crash not escalated to the root supervisor")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if c.addrs[0] != "inner" {
+		t.Errorf("root saw crash of %s, want inner", c.addrs[0])
+	}
+	waitLeaf()
+	if n := get(t, sys, "leaf"); n != 0 {
+		t.Errorf("leaf = %d after subtree restart, want 0", n)
+	}
+
+	// 再崩溃一次超过 root 的限制，root 也放弃并停止所有子节点
+	sys.Send("leaf", "crash")
+	select {
+	case <-root.Done():
+	case <-time.After(time.Second):
+		t.Fatal("root did not give up")
+	}
+	if err := root.Err(); !errors.Is(err, errCrash) {
+		t.Errorf("root Err() = %v, want wrapping errCrash", err)
+	}
+	for _, addr := range []Address{"leaf", "sibling"} {
+		if _, err := sys.lookup(addr); err == nil {
+			t.Errorf("%s still registered after root gave up", addr)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package batcher
+
+import (
+	"context"
+	"time"
+)
+
+// 批量消费者：receiveOnly 一次只处理一个元素，写存储时更希望一次写一批。
+// Batcher 从通道读取元素，满足以下任一条件就输出一批：
+//   - 攒够 Size 个元素
+//   - 距离本批第一个元素到达已经过了 Wait
+// 输入通道关闭时输出剩余的元素并关闭输出通道；ctx 取消时直接关闭输出通道，剩余元素丢弃。
+
+// Batcher 按数量和时间窗口分批
+type Batcher[T any] struct {
+	size  int
+	wait  time.Duration
+	clock Clock
+}
+
+// New 创建分批器，size 为每批最大元素数，wait 为一批从第一个元素起的最长等待时间
+func New[T any](size int, wait time.Duration) *Batcher[T] {
+	return &Batcher[T]{size: max(size, 1), wait: wait, clock: RealClock()}
+}
+
+// WithClock 替换时间来源，用于测试
+func (b *Batcher[T]) WithClock(clock Clock) *Batcher[T] {
+	b.clock = clock
+	return b
+}
+
+// Run 启动分批协程，返回输出批次的通道
+func (b *Batcher[T]) Run(ctx context.Context, in <-chan T) <-chan []T {
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+
+		var batch []T
+		var timer Timer
+		var timeout <-chan time.Time // 本批没有元素时为 nil，select 不会选中
+
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, timeout = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 {
+					timer = b.clock.NewTimer(b.wait)
+					timeout = timer.C()
+				}
+				if len(batch) >= b.size && !flush() {
+					return
+				}
+			case <-timeout:
+				timer, timeout = nil, nil
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
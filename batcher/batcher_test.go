@@ -0,0 +1,92 @@
+package batcher
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatcherSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		items []int
+		want  [][]int
+	}{
+		{"整批", 3, []int{1, 2, 3, 4, 5, 6}, [][]int{{1, 2, 3}, {4, 5, 6}}},
+		{"关闭时输出剩余", 3, []int{1, 2, 3, 4}, [][]int{{1, 2, 3}, {4}}},
+		{"空输入", 3, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan int)
+			clock := NewFakeClock(time.Unix(0, 0))
+			out := New[int](tt.size, time.Hour).WithClock(clock).Run(context.Background(), in)
+
+			go func() {
+				for _, v := range tt.items {
+					in <- v
+				}
+				close(in)
+			}()
+
+			var got [][]int
+			for batch := range out {
+				got = append(got, batch)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatcherWait(t *testing.T) {
+	in := make(chan int)
+	clock := NewFakeClock(time.Unix(0, 0))
+	out := New[int](10, 10*time.Second).WithClock(clock).Run(context.Background(), in)
+
+	// 计时从本批第一个元素开始，而不是最后一个
+	in <- 1
+	clock.BlockUntil(1)
+	clock.Advance(6 * time.Second)
+	in <- 2
+	clock.Advance(3 * time.Second)
+
+	select {
+	case batch := <-out:
+		t.Fatalf("提前输出 %v", batch)
+	default:
+	}
+
+	clock.Advance(time.Second)
+	if got := <-out; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("batch = %v, want [1 2]", got)
+	}
+
+	// 上一批输出后，下一批重新计时
+	in <- 3
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Second)
+	if got := <-out; !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("batch = %v, want [3]", got)
+	}
+
+	close(in)
+	if batch, ok := <-out; ok {
+		t.Errorf("输入关闭后又输出 %v", batch)
+	}
+}
+
+func TestBatcherCancel(t *testing.T) {
+	in := make(chan int)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := New[int](10, time.Hour).WithClock(NewFakeClock(time.Unix(0, 0))).Run(ctx, in)
+
+	in <- 1
+	cancel()
+	if batch, ok := <-out; ok {
+		t.Errorf("取消后输出 %v", batch)
+	}
+}
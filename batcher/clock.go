@@ -0,0 +1,117 @@
+package batcher
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时间来源，测试中用 FakeClock 代替真实时间
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 与 time.Timer 对应的定时器
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock 返回使用真实时间的 Clock
+func RealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+
+func (t realTimer) Stop() bool { return t.t.Stop() }
+
+// FakeClock 手动推进的时钟：只有调用 Advance 时时间才会前进、定时器才会触发
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock 创建从 now 开始的假时钟
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now 返回当前的假时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer 创建在假时间 d 之后触发的定时器
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance 把时间推进 d，并触发所有到期的定时器
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = pending
+	c.cond.Broadcast()
+}
+
+// BlockUntil 阻塞直到有 n 个未触发、未停止的定时器，
+// 用来确认被测协程已经开始计时，再调用 Advance
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 进程内发布/订阅：组件之间广播事件时不再手写通道。
+// 主题用 "." 分隔层级，订阅时可以使用通配符：
+//   - "*" 匹配恰好一层，例如 "order.*" 匹配 "order.created"
+//   - "#" 只能放在最后，匹配零层或多层，例如 "order.#" 匹配 "order" 和 "order.item.added"
+// 每个订阅者有自己的缓冲通道，缓冲满时按订阅时选择的慢消费者策略处理。
+
+// Policy 慢消费者策略：订阅者缓冲已满时如何处理新消息
+type Policy int
+
+const (
+	DropOldest Policy = iota // 丢弃缓冲中最旧的消息，放入新消息
+	DropNewest               // 丢弃新消息
+	Block                    // 阻塞发布者，直到订阅者腾出空间
+	Disconnect               // 断开该订阅者（关闭其通道）
+)
+
+// ErrClosed broker 已关闭
+var ErrClosed = errors.New("broker: 已关闭")
+
+// Message 一条消息
+type Message struct {
+	Topic   string
+	Payload any
+}
+
+// Metrics 订阅者的投递统计
+type Metrics struct {
+	Delivered    uint64 // 成功放入缓冲的消息数
+	Dropped      uint64 // 因缓冲满被丢弃的消息数
+	Blocked      uint64 // 投递时发生阻塞的次数
+	Pending      int    // 当前缓冲中未被取走的消息数
+	Disconnected bool   // 是否因消费过慢被断开
+}
+
+// Broker 进程内消息代理
+type Broker struct {
+	mu           sync.RWMutex
+	subs         map[*Subscription]struct{}
+	disconnected map[*Subscription]struct{} // 因消费过慢被断开、尚未调用 Unsubscribe 的订阅者，保留其统计
+	closed       bool
+}
+
+// New 创建 broker
+func New() *Broker {
+	return &Broker{
+		subs:         make(map[*Subscription]struct{}),
+		disconnected: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription 一个订阅，通过 C 接收消息；取消订阅或被断开后 C 会关闭
+type Subscription struct {
+	C <-chan Message
+
+	broker  *Broker
+	pattern []string
+	policy  Policy
+	ch      chan Message
+	done    chan struct{} // 取消订阅时关闭，让阻塞中的发布者退出
+	stop    sync.Once
+	mu      sync.RWMutex // 投递时持读锁，关闭 ch 时持写锁，保证不会向已关闭的通道发送
+	closed  bool
+
+	delivered    atomic.Uint64
+	dropped      atomic.Uint64
+	blocked      atomic.Uint64
+	disconnected atomic.Bool
+}
+
+// Subscribe 订阅匹配 pattern 的主题，buffer 为订阅者的缓冲大小。
+// 只有 Block 策略可以使用无缓冲（buffer 为 0），其他策略的缓冲至少为 1。
+// 通配符必须独占一层，"#" 只能是最后一层，否则返回错误。
+func (b *Broker) Subscribe(pattern string, buffer int, policy Policy) (*Subscription, error) {
+	levels := strings.Split(pattern, ".")
+	for i, level := range levels {
+		switch {
+		case level == "#" && i != len(levels)-1:
+			return nil, fmt.Errorf("broker: 订阅模式 %q 中 \"#\" 只能是最后一层", pattern)
+		case level != "#" && level != "*" && strings.ContainsAny(level, "#*"):
+			return nil, fmt.Errorf("broker: 订阅模式 %q 中通配符必须独占一层", pattern)
+		}
+	}
+	if policy != Block {
+		buffer = max(buffer, 1)
+	}
+	ch := make(chan Message, buffer)
+	sub := &Subscription{
+		C:       ch,
+		broker:  b,
+		pattern: levels,
+		policy:  policy,
+		ch:      ch,
+		done:    make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish 把消息投递给所有匹配主题的订阅者，返回投递成功的订阅者数
+func (b *Broker) Publish(topic string, payload any) (int, error) {
+	msg := Message{Topic: topic, Payload: payload}
+	levels := strings.Split(topic, ".")
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return 0, ErrClosed
+	}
+	var targets []*Subscription
+	for sub := range b.subs {
+		if match(sub.pattern, levels) {
+			targets = append(targets, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	// 在 broker 锁外投递，Block 策略的订阅者不会卡住订阅/取消订阅
+	delivered := 0
+	for _, sub := range targets {
+		if sub.deliver(msg) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// Close 关闭 broker 及所有订阅
+func (b *Broker) Close() {
+	b.mu.Lock()
+	subs := b.subs
+	b.subs = nil
+	b.disconnected = nil
+	b.closed = true
+	b.mu.Unlock()
+
+	for sub := range subs {
+		sub.close()
+	}
+}
+
+// Unsubscribe 取消订阅并关闭 C；被断开的订阅者调用后，其统计才会从 Broker.Metrics 中移除
+func (s *Subscription) Unsubscribe() {
+	s.broker.remove(s)
+	s.close()
+}
+
+// disconnect 因消费过慢断开：不再投递，但统计仍保留在 Broker.Metrics 中
+func (s *Subscription) disconnect() {
+	b := s.broker
+	b.mu.Lock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		b.disconnected[s] = struct{}{}
+	}
+	b.mu.Unlock()
+	s.close()
+}
+
+// Metrics 返回该订阅者的投递统计
+func (s *Subscription) Metrics() Metrics {
+	return Metrics{
+		Delivered:    s.delivered.Load(),
+		Dropped:      s.dropped.Load(),
+		Blocked:      s.blocked.Load(),
+		Pending:      len(s.ch),
+		Disconnected: s.disconnected.Load(),
+	}
+}
+
+// Metrics 返回所有订阅者（包括被断开但尚未 Unsubscribe 的）的投递统计，
+// 订阅的主题模式可通过 Subscription.Pattern 获取
+func (b *Broker) Metrics() map[*Subscription]Metrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	all := make(map[*Subscription]Metrics, len(b.subs)+len(b.disconnected))
+	for sub := range b.subs {
+		all[sub] = sub.Metrics()
+	}
+	for sub := range b.disconnected {
+		all[sub] = sub.Metrics()
+	}
+	return all
+}
+
+// Pattern 返回订阅的主题模式
+func (s *Subscription) Pattern() string {
+	return strings.Join(s.pattern, ".")
+}
+
+// deliver 按订阅者的策略投递一条消息，返回消息是否放入了缓冲
+func (s *Subscription) deliver(msg Message) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case <-s.done: // 正在断开
+		return false
+	default:
+	}
+
+	select {
+	case s.ch <- msg:
+		s.delivered.Add(1)
+		return true
+	default:
+	}
+
+	// 缓冲已满
+	switch s.policy {
+	case Block:
+		s.blocked.Add(1)
+		select {
+		case s.ch <- msg:
+			s.delivered.Add(1)
+			return true
+		case <-s.done:
+			return false
+		}
+	case DropOldest:
+		// 可能有多个发布者同时投递，循环直到放入
+		for {
+			select {
+			case s.ch <- msg:
+				s.delivered.Add(1)
+				return true
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	case Disconnect:
+		s.dropped.Add(1)
+		s.disconnected.Store(true)
+		s.stop.Do(func() { close(s.done) })
+		go s.disconnect() // 持有读锁时不能关闭 ch
+		return false
+	default: // DropNewest
+		s.dropped.Add(1)
+		return false
+	}
+}
+
+// close 先通知阻塞中的发布者退出，再等它们释放读锁后关闭 ch
+func (s *Subscription) close() {
+	s.stop.Do(func() { close(s.done) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+func (b *Broker) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, s)
+	delete(b.disconnected, s)
+}
+
+// match 判断主题层级是否匹配订阅模式
+func match(pattern, topic []string) bool {
+	for i, p := range pattern {
+		if p == "#" {
+			return i == len(pattern)-1
+		}
+		if i >= len(topic) || (p != "*" && p != topic[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(topic)
+}
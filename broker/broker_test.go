@@ -0,0 +1,211 @@
+package broker
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"order.created", "order.created", true},
+		{"order.created", "order.paid", false},
+		{"order.*", "order.created", true},
+		{"order.*", "order", false},
+		{"order.*", "order.item.added", false},
+		{"*.created", "user.created", true},
+		{"order.#", "order", true},
+		{"order.#", "order.created", true},
+		{"order.#", "order.item.added", true},
+		{"order.#", "user.created", false},
+		{"#", "anything.at.all", true},
+		{"order.*.added", "order.item.added", true},
+		{"order.*.#", "order", false},
+		{"order.*.#", "order.item", true},
+	}
+	for _, tt := range tests {
+		got := match(strings.Split(tt.pattern, "."), strings.Split(tt.topic, "."))
+		if got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribeInvalidPattern(t *testing.T) {
+	b := New()
+	defer b.Close()
+	for _, pattern := range []string{"#.order", "order.#.created", "order.cre*", "or#der"} {
+		if _, err := b.Subscribe(pattern, 1, DropNewest); err == nil {
+			t.Errorf("Subscribe(%q): want error", pattern)
+		}
+	}
+	if len(b.Metrics()) != 0 {
+		t.Error("invalid subscription was registered")
+	}
+}
+
+// drain 取出缓冲中的所有消息
+func drain(sub *Subscription) []any {
+	var payloads []any
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				return payloads
+			}
+			payloads = append(payloads, msg.Payload)
+		default:
+			return payloads
+		}
+	}
+}
+
+func TestPublishRouting(t *testing.T) {
+	b := New()
+	defer b.Close()
+	all, _ := b.Subscribe("order.#", 10, DropNewest)
+	created, _ := b.Subscribe("order.created", 10, DropNewest)
+	other, _ := b.Subscribe("user.*", 10, DropNewest)
+
+	if n, _ := b.Publish("order.created", 1); n != 2 {
+		t.Errorf("Publish(order.created) delivered to %d, want 2", n)
+	}
+	b.Publish("order.paid", 2)
+
+	if got := drain(all); !reflect.DeepEqual(got, []any{1, 2}) {
+		t.Errorf("order.# got %v", got)
+	}
+	if got := drain(created); !reflect.DeepEqual(got, []any{1}) {
+		t.Errorf("order.created got %v", got)
+	}
+	if got := drain(other); got != nil {
+		t.Errorf("user.* got %v", got)
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	b := New()
+	defer b.Close()
+	sub, _ := b.Subscribe("t", 2, DropOldest)
+	for i := 1; i <= 5; i++ {
+		b.Publish("t", i)
+	}
+	if got := drain(sub); !reflect.DeepEqual(got, []any{4, 5}) {
+		t.Errorf("got %v, want [4 5]", got)
+	}
+	if m := sub.Metrics(); m.Delivered != 5 || m.Dropped != 3 {
+		t.Errorf("metrics = %+v, want delivered 5, dropped 3", m)
+	}
+}
+
+func TestDropNewest(t *testing.T) {
+	b := New()
+	defer b.Close()
+	sub, _ := b.Subscribe("t", 2, DropNewest)
+	for i := 1; i <= 5; i++ {
+		b.Publish("t", i)
+	}
+	if got := drain(sub); !reflect.DeepEqual(got, []any{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+	if m := sub.Metrics(); m.Delivered != 2 || m.Dropped != 3 {
+		t.Errorf("metrics = %+v, want delivered 2, dropped 3", m)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	b := New()
+	defer b.Close()
+	sub, _ := b.Subscribe("t", 0, Block)
+
+	published := make(chan int)
+	go func() {
+		n, _ := b.Publish("t", 1)
+		published <- n
+	}()
+
+	// 等发布者阻塞后再接收
+	for sub.Metrics().Blocked == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-published:
+		t.Fatal("Publish returned before the subscriber received")
+	default:
+	}
+	if msg := <-sub.C; msg.Payload != 1 {
+		t.Errorf("payload = %v, want 1", msg.Payload)
+	}
+	if n := <-published; n != 1 {
+		t.Errorf("Publish delivered to %d, want 1", n)
+	}
+
+	// 取消订阅会让阻塞中的发布者退出
+	go func() {
+		n, _ := b.Publish("t", 2)
+		published <- n
+	}()
+	for sub.Metrics().Blocked < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	sub.Unsubscribe()
+	if n := <-published; n != 0 {
+		t.Errorf("Publish after Unsubscribe delivered to %d, want 0", n)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	b := New()
+	defer b.Close()
+	slow, _ := b.Subscribe("t", 1, Disconnect)
+	fast, _ := b.Subscribe("t", 10, Disconnect)
+
+	b.Publish("t", 1)
+	b.Publish("t", 2)
+
+	var got []any
+	for msg := range slow.C { // 断开后 C 关闭
+		got = append(got, msg.Payload)
+	}
+	if !reflect.DeepEqual(got, []any{1}) {
+		t.Errorf("slow got %v, want [1]", got)
+	}
+	if n, _ := b.Publish("t", 3); n != 1 {
+		t.Errorf("Publish after disconnect delivered to %d, want 1", n)
+	}
+
+	// 被断开的订阅者统计仍然可见，直到 Unsubscribe
+	metrics := b.Metrics()
+	if m, ok := metrics[slow]; !ok || !m.Disconnected || m.Dropped != 1 || m.Delivered != 1 {
+		t.Errorf("slow metrics = %+v, %v", m, ok)
+	}
+	if m := metrics[fast]; m.Disconnected || m.Delivered != 3 {
+		t.Errorf("fast metrics = %+v", m)
+	}
+	slow.Unsubscribe()
+	if _, ok := b.Metrics()[slow]; ok {
+		t.Error("metrics kept after Unsubscribe")
+	}
+}
+
+func TestClose(t *testing.T) {
+	b := New()
+	sub, _ := b.Subscribe("#", 1, DropNewest)
+	b.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Error("C not closed after broker Close")
+	}
+	if _, err := b.Publish("t", 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Close = %v, want ErrClosed", err)
+	}
+	if _, err := b.Subscribe("t", 1, DropNewest); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close = %v, want ErrClosed", err)
+	}
+	sub.Unsubscribe() // 关闭后取消订阅不会 panic
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 并发缓存：five_mu 的笔记里把"缓存系统"作为读写锁的典型场景，这里给出三种实现：
+//   - RWMutexCache：一把读写锁保护一个 map，读操作只持读锁
+//   - ShardedCache：按键的哈希分成多个 RWMutexCache，写操作只锁一个分片
+//   - SyncMapCache：基于 sync.Map，读取完全无锁，适合键集合稳定、读远多于写的场景
+//
+// 共同特性：
+//   - TTL 过期：读到过期的条目视为未命中并删除；此外每隔一个 TTL，由到期后的第一次读写顺带清理所有过期条目，
+//     不再被读取的键也会释放，清理的代价平摊到一个 TTL 内的所有操作上
+//   - 容量上限：条目数超过 MaxEntries 时淘汰。为了让读操作只持读锁，最近访问时间用原子变量记录，
+//     淘汰时随机抽样 evictionSamples 个条目，优先淘汰已过期的，否则淘汰最久未访问的（近似 LRU，与 Redis 的做法相同）
+//   - GetOrLoad：未命中时调用加载函数，同一个键并发的加载只执行一次（singleflight），加载失败不缓存；
+//     加载函数 panic 时，发起加载的协程继续 panic，等待它的协程得到 ErrLoadPanicked
+//   - Stats：命中、未命中、加载、淘汰等统计
+
+// ErrLoadPanicked 与之合并的加载函数 panic 时 GetOrLoad 返回的错误
+var ErrLoadPanicked = errors.New("cache: 加载函数 panic")
+
+// evictionSamples 淘汰时抽样的条目数；条目数不超过它时就是精确的 LRU
+const evictionSamples = 5
+
+// Cache 三种实现共同的接口
+type Cache[K comparable, V any] interface {
+	// Get 返回未过期的值，命中时刷新最近访问时间
+	Get(key K) (V, bool)
+	// Set 写入值，超过容量时淘汰其他条目
+	Set(key K, value V)
+	// Delete 删除条目
+	Delete(key K)
+	// GetOrLoad 命中时直接返回，否则调用 load 并缓存其结果；同一个键并发的加载只执行一次
+	GetOrLoad(key K, load func(K) (V, error)) (V, error)
+	// Len 返回条目数（可能包含尚未清理的过期条目）
+	Len() int
+	// Stats 返回统计
+	Stats() Stats
+}
+
+// Options 缓存配置
+type Options struct {
+	TTL        time.Duration    // 条目的存活时间，0 表示不过期
+	MaxEntries int              // 条目数上限，0 表示不限制
+	Now        func() time.Time // 时间源，默认 time.Now，测试时可替换
+}
+
+func (o Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// Stats 缓存统计
+type Stats struct {
+	Hits        uint64 // 命中次数
+	Misses      uint64 // 未命中次数（包括读到过期条目）
+	Loads       uint64 // 实际执行加载函数的次数
+	LoadErrors  uint64 // 加载失败的次数
+	Shared      uint64 // 等待其他协程加载结果、未重复加载的次数
+	Evictions   uint64 // 因容量上限淘汰的条目数
+	Expirations uint64 // 因过期删除的条目数
+}
+
+// HitRate 命中率
+func (s Stats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// add 累加另一份统计，用于汇总分片
+func (s Stats) add(o Stats) Stats {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Loads += o.Loads
+	s.LoadErrors += o.LoadErrors
+	s.Shared += o.Shared
+	s.Evictions += o.Evictions
+	s.Expirations += o.Expirations
+	return s
+}
+
+// counters 并发更新的统计计数
+type counters struct {
+	hits, misses, loads, loadErrors, shared, evictions, expirations atomic.Uint64
+}
+
+func (c *counters) snapshot() Stats {
+	return Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Loads:       c.loads.Load(),
+		LoadErrors:  c.loadErrors.Load(),
+		Shared:      c.shared.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+}
+
+// entry 缓存条目。写入后值不再修改，只有最近访问时间会被并发更新
+type entry[V any] struct {
+	value   V
+	expires int64        // 过期时间（UnixNano），0 表示不过期
+	access  atomic.Int64 // 最近访问时间（UnixNano）
+}
+
+func newEntry[V any](value V, now time.Time, ttl time.Duration) *entry[V] {
+	e := &entry[V]{value: value}
+	if ttl > 0 {
+		e.expires = now.Add(ttl).UnixNano()
+	}
+	e.access.Store(now.UnixNano())
+	return e
+}
+
+func (e *entry[V]) expired(now int64) bool {
+	return e.expires != 0 && now >= e.expires
+}
+
+// sweeper 决定何时清理全部过期条目
+type sweeper struct {
+	next atomic.Int64 // 下次清理的时间（UnixNano）
+}
+
+// due 距上次清理已过 ttl 时返回 true，并发调用时只有一个返回 true；ttl 为 0 时总是 false
+func (s *sweeper) due(now int64, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	next := s.next.Load()
+	return now >= next && s.next.CompareAndSwap(next, now+int64(ttl))
+}
+
+// call 一次正在进行的加载
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	dups  int // 等待这次加载的协程数，由 group.mu 保护
+}
+
+// group 按键合并并发的加载
+type group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// do 执行 fn，同一个键已有加载在进行时等待它的结果；shared 表示结果来自其他协程的加载
+func (g *group[K, V]) do(key K, fn func() (V, error)) (value V, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		<-c.done
+		return c.value, c.err, true
+	}
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	returned := false
+	defer func() {
+		// fn panic 或调用了 runtime.Goexit：先记下错误再唤醒等待者，否则它们会拿到零值和 nil 错误
+		var r any
+		if !returned {
+			r = recover()
+			c.err = fmt.Errorf("%w：%v", ErrLoadPanicked, r)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+	c.value, c.err = fn()
+	returned = true
+	return c.value, c.err, false
+}
+
+// backend 各实现提供给 loadThrough 的操作
+type backend[K comparable, V any] interface {
+	lookup(key K) (V, bool) // 与 Get 相同，但不计入统计
+	Set(key K, value V)
+}
+
+// loadThrough 未命中后的加载流程：合并并发加载，加载前再查一次（可能刚被其他协程写入），成功后写入缓存
+func loadThrough[K comparable, V any](b backend[K, V], g *group[K, V], stats *counters, key K, load func(K) (V, error)) (V, error) {
+	value, err, shared := g.do(key, func() (V, error) {
+		if v, ok := b.lookup(key); ok {
+			return v, nil
+		}
+		stats.loads.Add(1)
+		v, err := load(key)
+		if err != nil {
+			stats.loadErrors.Add(1)
+			return v, err
+		}
+		b.Set(key, v)
+		return v, nil
+	})
+	if shared {
+		stats.shared.Add(1)
+	}
+	return value, err
+}
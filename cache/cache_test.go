@@ -0,0 +1,346 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	_ Cache[string, int] = (*RWMutexCache[string, int])(nil)
+	_ Cache[string, int] = (*ShardedCache[string, int])(nil)
+	_ Cache[string, int] = (*SyncMapCache[string, int])(nil)
+)
+
+// fakeClock 手动推进的时间源
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// implementations 三种实现的构造函数，分片版本只用 1 个分片，以便精确检查容量和淘汰
+var implementations = []struct {
+	name string
+	new  func(Options) Cache[string, int]
+}{
+	{"RWMutex", func(o Options) Cache[string, int] { return NewRWMutex[string, int](o) }},
+	{"Sharded", func(o Options) Cache[string, int] { return NewSharded[string, int](1, o) }},
+	{"SyncMap", func(o Options) Cache[string, int] { return NewSyncMap[string, int](o) }},
+}
+
+func TestCache(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			t.Run("GetSetDelete", func(t *testing.T) { testGetSetDelete(t, impl.new) })
+			t.Run("TTL", func(t *testing.T) { testTTL(t, impl.new) })
+			t.Run("LRU", func(t *testing.T) { testLRU(t, impl.new) })
+			t.Run("HotSet", func(t *testing.T) { testHotSet(t, impl.new) })
+			t.Run("Sweep", func(t *testing.T) { testSweep(t, impl.new) })
+			t.Run("GetOrLoad", func(t *testing.T) { testGetOrLoad(t, impl.new) })
+			t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, impl.new) })
+		})
+	}
+}
+
+func testGetSetDelete(t *testing.T, newCache func(Options) Cache[string, int]) {
+	c := newCache(Options{})
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("空缓存命中")
+	}
+	c.Set("a", 1)
+	c.Set("a", 2)
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Fatalf("Get = %d, %v, want 2, true", v, ok)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len = %d, want 1", c.Len())
+	}
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Errorf("删除后仍命中或 Len = %d", c.Len())
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 2 {
+		t.Errorf("Stats = %+v, want 1 次命中 2 次未命中", s)
+	}
+}
+
+func testTTL(t *testing.T, newCache func(Options) Cache[string, int]) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := newCache(Options{TTL: time.Minute, Now: clock.Now})
+	c.Set("a", 1)
+	clock.Advance(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("未到期就过期")
+	}
+	clock.Advance(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("到期后仍命中")
+	}
+	if s := c.Stats(); s.Expirations != 1 || c.Len() != 0 {
+		t.Errorf("Expirations = %d, Len = %d, want 1, 0", s.Expirations, c.Len())
+	}
+}
+
+func testLRU(t *testing.T, newCache func(Options) Cache[string, int]) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := newCache(Options{MaxEntries: 3, Now: clock.Now})
+	for i, k := range []string{"a", "b", "c"} {
+		c.Set(k, i)
+		clock.Advance(time.Second)
+	}
+	c.Get("a") // a 变为最近访问，b 最久未访问
+	clock.Advance(time.Second)
+	c.Set("d", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("最久未访问的 b 没有被淘汰")
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("%s 被错误淘汰", k)
+		}
+	}
+	if s := c.Stats(); s.Evictions != 1 || c.Len() != 3 {
+		t.Errorf("Evictions = %d, Len = %d, want 1, 3", s.Evictions, c.Len())
+	}
+}
+
+func testHotSet(t *testing.T, newCache func(Options) Cache[string, int]) {
+	// 反复访问少量热键，同时不断写入只用一次的冷键：抽样淘汰应该几乎总是淘汰冷键
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := newCache(Options{MaxEntries: 100, Now: clock.Now})
+	const hot, rounds = 10, 10000
+	misses := 0
+	for i := range rounds {
+		for k := range hot {
+			key := fmt.Sprint("hot", k)
+			if _, ok := c.Get(key); !ok {
+				misses++
+				c.Set(key, k)
+			}
+			clock.Advance(time.Microsecond)
+		}
+		c.Set(fmt.Sprint("cold", i), i)
+		clock.Advance(time.Microsecond)
+	}
+	// 第一轮的 hot 次未命中不可避免，此后热键的未命中率应低于 1%
+	if limit := hot + rounds*hot/100; misses > limit {
+		t.Errorf("热键未命中 %d 次, want <= %d", misses, limit)
+	}
+}
+
+func testSweep(t *testing.T, newCache func(Options) Cache[string, int]) {
+	// 过期后不再读取的键也要被清理
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c := newCache(Options{TTL: time.Minute, Now: clock.Now})
+	for i := range 100 {
+		c.Set(fmt.Sprint(i), i)
+	}
+	clock.Advance(30 * time.Second)
+	c.Get("other")
+	if n := c.Len(); n != 100 {
+		t.Fatalf("未到期时 Len = %d, want 100", n)
+	}
+	clock.Advance(time.Minute)
+	c.Get("other")
+	if n, s := c.Len(), c.Stats(); n != 0 || s.Expirations != 100 {
+		t.Errorf("到期后 Len = %d, Expirations = %d, want 0, 100", n, s.Expirations)
+	}
+}
+
+func testGetOrLoad(t *testing.T, newCache func(Options) Cache[string, int]) {
+	c := newCache(Options{})
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(key string) (int, error) {
+		loads.Add(1)
+		<-release
+		return len(key), nil
+	}
+
+	// 并发加载同一个键只执行一次
+	const callers = 10
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.GetOrLoad("abc", load); err != nil || v != 3 {
+				t.Errorf("GetOrLoad = %d, %v, want 3, nil", v, err)
+			}
+		}()
+	}
+	for c.Stats().Misses < callers {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("加载 %d 次, want 1", n)
+	}
+	if s := c.Stats(); s.Loads != 1 {
+		t.Errorf("Stats.Loads = %d, want 1", s.Loads)
+	}
+
+	// 加载失败不缓存
+	errLoad := errors.New("加载失败")
+	if _, err := c.GetOrLoad("x", func(string) (int, error) { return 0, errLoad }); !errors.Is(err, errLoad) {
+		t.Errorf("err = %v, want %v", err, errLoad)
+	}
+	if _, ok := c.Get("x"); ok {
+		t.Error("加载失败的结果被缓存")
+	}
+	if s := c.Stats(); s.LoadErrors != 1 {
+		t.Errorf("Stats.LoadErrors = %d, want 1", s.LoadErrors)
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	var g group[string, int]
+	release := make(chan struct{})
+	leader := make(chan any)
+	go func() {
+		defer func() { leader <- recover() }()
+		g.do("k", func() (int, error) {
+			<-release
+			panic("boom")
+		})
+	}()
+
+	// 等发起加载的协程登记后再加入等待
+	waitCall := func(dups int) bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c, ok := g.calls["k"]
+		return ok && c.dups >= dups
+	}
+	for !waitCall(0) {
+		runtime.Gosched()
+	}
+	waiter := make(chan error)
+	go func() {
+		_, err, _ := g.do("k", func() (int, error) { return 1, nil })
+		waiter <- err
+	}()
+	for !waitCall(1) {
+		runtime.Gosched()
+	}
+	close(release)
+
+	if r := <-leader; r != "boom" {
+		t.Errorf("发起加载的协程 recover() = %v, want boom", r)
+	}
+	if err := <-waiter; !errors.Is(err, ErrLoadPanicked) {
+		t.Errorf("等待者 err = %v, want %v", err, ErrLoadPanicked)
+	}
+	if len(g.calls) != 0 {
+		t.Error("panic 后加载记录没有删除")
+	}
+}
+
+func testConcurrent(t *testing.T, newCache func(Options) Cache[string, int]) {
+	c := newCache(Options{MaxEntries: 50, TTL: time.Hour})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 2000 {
+				key := fmt.Sprint((g*7 + i) % 100)
+				switch i % 4 {
+				case 0:
+					c.Set(key, i)
+				case 1:
+					c.Delete(key)
+				case 2:
+					c.GetOrLoad(key, func(string) (int, error) { return i, nil })
+				default:
+					c.Get(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Len(); n > 50 {
+		t.Errorf("Len = %d, 超过上限 50", n)
+	}
+}
+
+// 基准测试：8 个分片对比单锁和 sync.Map，键空间 1000，容量足够（不触发淘汰）
+
+const benchKeys = 1000
+
+var benchImpls = []struct {
+	name string
+	new  func() Cache[int, int]
+}{
+	{"RWMutex", func() Cache[int, int] { return NewRWMutex[int, int](Options{}) }},
+	{"Sharded", func() Cache[int, int] { return NewSharded[int, int](8, Options{}) }},
+	{"SyncMap", func() Cache[int, int] { return NewSyncMap[int, int](Options{}) }},
+}
+
+func BenchmarkCache(b *testing.B) {
+	for _, readPercent := range []int{100, 90, 50} {
+		for _, impl := range benchImpls {
+			b.Run(fmt.Sprintf("read=%d%%/%s", readPercent, impl.name), func(b *testing.B) {
+				c := impl.new()
+				for i := range benchKeys {
+					c.Set(i, i)
+				}
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+					for pb.Next() {
+						key := r.IntN(benchKeys)
+						if r.IntN(100) < readPercent {
+							c.Get(key)
+						} else {
+							c.Set(key, key)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkGetOrLoad(b *testing.B) {
+	// 容量只有键空间的一半，随机访问时命中率约 50%，命中、淘汰和加载都会发生
+	for _, impl := range []struct {
+		name string
+		new  func() Cache[int, int]
+	}{
+		{"RWMutex", func() Cache[int, int] { return NewRWMutex[int, int](Options{MaxEntries: benchKeys / 2}) }},
+		{"Sharded", func() Cache[int, int] { return NewSharded[int, int](8, Options{MaxEntries: benchKeys / 2}) }},
+		{"SyncMap", func() Cache[int, int] { return NewSyncMap[int, int](Options{MaxEntries: benchKeys / 2}) }},
+	} {
+		b.Run(impl.name, func(b *testing.B) {
+			c := impl.new()
+			load := func(k int) (int, error) { return k * 2, nil }
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+				for pb.Next() {
+					c.GetOrLoad(r.IntN(benchKeys), load)
+				}
+			})
+			b.ReportMetric(c.Stats().HitRate()*100, "hit%")
+		})
+	}
+}
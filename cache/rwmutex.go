@@ -0,0 +1,129 @@
+package cache
+
+import "sync"
+
+// RWMutexCache 一把读写锁保护的缓存：Get 只持读锁，Set、Delete 和淘汰持写锁
+type RWMutexCache[K comparable, V any] struct {
+	opts    Options
+	mu      sync.RWMutex
+	entries map[K]*entry[V]
+	loads   group[K, V]
+	stats   counters
+	sweep   sweeper
+}
+
+// NewRWMutex 创建读写锁版本的缓存
+func NewRWMutex[K comparable, V any](opts Options) *RWMutexCache[K, V] {
+	return &RWMutexCache[K, V]{opts: opts, entries: make(map[K]*entry[V])}
+}
+
+func (c *RWMutexCache[K, V]) Get(key K) (V, bool) {
+	v, ok := c.lookup(key)
+	if ok {
+		c.stats.hits.Add(1)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	return v, ok
+}
+
+func (c *RWMutexCache[K, V]) lookup(key K) (V, bool) {
+	now := c.opts.now().UnixNano()
+	if c.sweep.due(now, c.opts.TTL) {
+		c.mu.Lock()
+		c.removeExpired(now)
+		c.mu.Unlock()
+	}
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	var zero V
+	if !ok {
+		return zero, false
+	}
+	if e.expired(now) {
+		c.mu.Lock()
+		// 期间可能已被重新写入，只删除读到的那个条目
+		if c.entries[key] == e {
+			delete(c.entries, key)
+			c.stats.expirations.Add(1)
+		}
+		c.mu.Unlock()
+		return zero, false
+	}
+	e.access.Store(now)
+	return e.value, true
+}
+
+func (c *RWMutexCache[K, V]) Set(key K, value V) {
+	e := newEntry(value, c.opts.now(), c.opts.TTL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now := e.access.Load(); c.sweep.due(now, c.opts.TTL) {
+		c.removeExpired(now)
+	}
+	if _, ok := c.entries[key]; !ok && c.opts.MaxEntries > 0 {
+		for len(c.entries) >= c.opts.MaxEntries {
+			c.evict(e.access.Load())
+		}
+	}
+	c.entries[key] = e
+}
+
+// evict 抽样淘汰一个条目：有过期的先删过期的，否则删最久未访问的（调用方持有写锁）
+func (c *RWMutexCache[K, V]) evict(now int64) {
+	var victim K
+	var oldest *entry[V]
+	n := 0
+	for k, e := range c.entries { // map 的遍历顺序是随机的，前几个即为随机样本
+		if e.expired(now) {
+			delete(c.entries, k)
+			c.stats.expirations.Add(1)
+			return
+		}
+		if oldest == nil || e.access.Load() < oldest.access.Load() {
+			victim, oldest = k, e
+		}
+		if n++; n >= evictionSamples {
+			break
+		}
+	}
+	if oldest != nil {
+		delete(c.entries, victim)
+		c.stats.evictions.Add(1)
+	}
+}
+
+// removeExpired 删除所有过期条目（调用方持有写锁）
+func (c *RWMutexCache[K, V]) removeExpired(now int64) {
+	for k, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, k)
+			c.stats.expirations.Add(1)
+		}
+	}
+}
+
+func (c *RWMutexCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func (c *RWMutexCache[K, V]) GetOrLoad(key K, load func(K) (V, error)) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	return loadThrough[K, V](c, &c.loads, &c.stats, key, load)
+}
+
+func (c *RWMutexCache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+func (c *RWMutexCache[K, V]) Stats() Stats {
+	return c.stats.snapshot()
+}
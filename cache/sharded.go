@@ -0,0 +1,59 @@
+package cache
+
+import "hash/maphash"
+
+// ShardedCache 按键的哈希分成多个 RWMutexCache，不同分片上的写操作互不阻塞。
+// 容量上限平均分给各分片，因此淘汰只在分片内进行
+type ShardedCache[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*RWMutexCache[K, V]
+}
+
+// NewSharded 创建 shards 个分片的缓存
+func NewSharded[K comparable, V any](shards int, opts Options) *ShardedCache[K, V] {
+	shards = max(shards, 1)
+	if opts.MaxEntries > 0 {
+		opts.MaxEntries = max((opts.MaxEntries+shards-1)/shards, 1)
+	}
+	c := &ShardedCache[K, V]{seed: maphash.MakeSeed(), shards: make([]*RWMutexCache[K, V], shards)}
+	for i := range c.shards {
+		c.shards[i] = NewRWMutex[K, V](opts)
+	}
+	return c
+}
+
+func (c *ShardedCache[K, V]) shard(key K) *RWMutexCache[K, V] {
+	return c.shards[maphash.Comparable(c.seed, key)%uint64(len(c.shards))]
+}
+
+func (c *ShardedCache[K, V]) Get(key K) (V, bool) {
+	return c.shard(key).Get(key)
+}
+
+func (c *ShardedCache[K, V]) Set(key K, value V) {
+	c.shard(key).Set(key, value)
+}
+
+func (c *ShardedCache[K, V]) Delete(key K) {
+	c.shard(key).Delete(key)
+}
+
+func (c *ShardedCache[K, V]) GetOrLoad(key K, load func(K) (V, error)) (V, error) {
+	return c.shard(key).GetOrLoad(key, load)
+}
+
+func (c *ShardedCache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Len()
+	}
+	return n
+}
+
+func (c *ShardedCache[K, V]) Stats() Stats {
+	var total Stats
+	for _, s := range c.shards {
+		total = total.add(s.Stats())
+	}
+	return total
+}
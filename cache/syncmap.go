@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// SyncMapCache 基于 sync.Map 的缓存，Get 完全无锁。
+// sync.Map 的 Range 顺序由键的哈希决定，前几个条目总是同一批，不能当作随机样本；
+// 因此另用一把互斥锁保护所有写操作和一份键的列表，淘汰时从列表中随机抽样。
+// 写操作因此与 RWMutexCache 一样串行，只适合读远多于写的场景
+type SyncMapCache[K comparable, V any] struct {
+	opts  Options
+	m     sync.Map // K -> *entry[V]
+	mu    sync.Mutex
+	keys  []K       // 所有键，顺序无意义
+	index map[K]int // 键在 keys 中的下标
+	loads group[K, V]
+	stats counters
+	sweep sweeper
+}
+
+// NewSyncMap 创建 sync.Map 版本的缓存
+func NewSyncMap[K comparable, V any](opts Options) *SyncMapCache[K, V] {
+	return &SyncMapCache[K, V]{opts: opts, index: make(map[K]int)}
+}
+
+func (c *SyncMapCache[K, V]) Get(key K) (V, bool) {
+	v, ok := c.lookup(key)
+	if ok {
+		c.stats.hits.Add(1)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	return v, ok
+}
+
+func (c *SyncMapCache[K, V]) lookup(key K) (V, bool) {
+	var zero V
+	now := c.opts.now().UnixNano()
+	if c.sweep.due(now, c.opts.TTL) {
+		c.mu.Lock()
+		c.removeExpired(now)
+		c.mu.Unlock()
+	}
+	v, ok := c.m.Load(key)
+	if !ok {
+		return zero, false
+	}
+	e := v.(*entry[V])
+	if e.expired(now) {
+		c.mu.Lock()
+		if c.m.CompareAndDelete(key, e) {
+			c.removeKey(key)
+			c.stats.expirations.Add(1)
+		}
+		c.mu.Unlock()
+		return zero, false
+	}
+	e.access.Store(now)
+	return e.value, true
+}
+
+func (c *SyncMapCache[K, V]) Set(key K, value V) {
+	e := newEntry(value, c.opts.now(), c.opts.TTL)
+	now := e.access.Load()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sweep.due(now, c.opts.TTL) {
+		c.removeExpired(now)
+	}
+	if _, loaded := c.m.Swap(key, e); loaded {
+		return
+	}
+	c.index[key] = len(c.keys)
+	c.keys = append(c.keys, key)
+	for c.opts.MaxEntries > 0 && len(c.keys) > c.opts.MaxEntries {
+		c.evict(key, now)
+	}
+}
+
+// evict 从 keys 中不放回地抽样，淘汰一个条目，不淘汰刚写入的 keep（调用方持有 mu）。
+// 抽样用部分 Fisher-Yates 洗牌，把样本换到 keys 开头
+func (c *SyncMapCache[K, V]) evict(keep K, now int64) {
+	var victim K
+	var oldest *entry[V]
+	for i := range min(evictionSamples, len(c.keys)) {
+		j := i + rand.IntN(len(c.keys)-i)
+		c.keys[i], c.keys[j] = c.keys[j], c.keys[i]
+		c.index[c.keys[i]], c.index[c.keys[j]] = i, j
+
+		key := c.keys[i]
+		if key == keep {
+			continue
+		}
+		v, _ := c.m.Load(key) // 写操作都持有 mu，keys 中的键一定在 m 中
+		e := v.(*entry[V])
+		if e.expired(now) {
+			c.m.Delete(key)
+			c.removeKey(key)
+			c.stats.expirations.Add(1)
+			return
+		}
+		if oldest == nil || e.access.Load() < oldest.access.Load() {
+			victim, oldest = key, e
+		}
+	}
+	if oldest != nil {
+		c.m.Delete(victim)
+		c.removeKey(victim)
+		c.stats.evictions.Add(1)
+	}
+}
+
+// removeExpired 删除所有过期条目（调用方持有 mu）
+func (c *SyncMapCache[K, V]) removeExpired(now int64) {
+	c.m.Range(func(k, v any) bool {
+		if v.(*entry[V]).expired(now) {
+			c.m.Delete(k)
+			c.removeKey(k.(K))
+			c.stats.expirations.Add(1)
+		}
+		return true
+	})
+}
+
+// removeKey 把 key 从 keys 中删除：用最后一个键填补它的位置（调用方持有 mu）
+func (c *SyncMapCache[K, V]) removeKey(key K) {
+	i := c.index[key]
+	last := c.keys[len(c.keys)-1]
+	c.keys[i] = last
+	c.index[last] = i
+	c.keys = c.keys[:len(c.keys)-1]
+	delete(c.index, key)
+}
+
+func (c *SyncMapCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, loaded := c.m.LoadAndDelete(key); loaded {
+		c.removeKey(key)
+	}
+}
+
+func (c *SyncMapCache[K, V]) GetOrLoad(key K, load func(K) (V, error)) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	return loadThrough[K, V](c, &c.loads, &c.stats, key, load)
+}
+
+func (c *SyncMapCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.keys)
+}
+
+func (c *SyncMapCache[K, V]) Stats() Stats {
+	return c.stats.snapshot()
+}
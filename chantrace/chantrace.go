@@ -0,0 +1,262 @@
+package chantrace
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 通道操作追踪：把通道包装一层，记录每次发送、接收、阻塞、关闭发生在哪个协程、什么时间，
+// 再把记录渲染成文本时间线或 Mermaid 时序图，用来讲解和排查生产者/消费者程序。
+// 阻塞的判断方式：先做一次非阻塞尝试，失败就记一条"阻塞"事件，再做真正的阻塞操作。
+// 例如无缓冲通道上，发送方会先记录"阻塞 发送"，直到接收方取走元素后才记录"发送"完成。
+//
+// 事件的顺序：记录器有一个原子递增的逻辑时钟，每个操作开始前取一次（Begin），完成后再取一次（Seq），
+// 操作真正生效的时刻一定落在 (Begin, Seq) 之间。事件按 Seq 排序，
+// 因此无缓冲通道上配对的发送和接收，各自的区间一定相交：发送不会在接收开始之前就完成。
+
+// Op 通道操作类型
+type Op int
+
+const (
+	OpSend       Op = iota // 发送完成
+	OpRecv                 // 接收完成
+	OpBlockSend            // 发送阻塞（通道已满或没有接收方）
+	OpBlockRecv            // 接收阻塞（通道为空）
+	OpClose                // 关闭通道
+	OpRecvClosed           // 从已关闭的通道接收（ok 为 false）
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpSend:
+		return "发送"
+	case OpRecv:
+		return "接收"
+	case OpBlockSend:
+		return "阻塞 发送"
+	case OpBlockRecv:
+		return "阻塞 接收"
+	case OpClose:
+		return "关闭"
+	case OpRecvClosed:
+		return "接收（已关闭）"
+	}
+	return "未知"
+}
+
+// Event 一次通道操作
+type Event struct {
+	Seq       int           // 操作完成后取得的逻辑时钟，事件按它排序
+	Begin     int           // 操作开始前取得的逻辑时钟，阻塞和关闭以外的事件 Begin < Seq
+	At        time.Duration // 距离 Recorder 创建的时间
+	Goroutine string        // 协程名（通过 Name 设置），未命名时为 "g<id>"
+	Channel   string
+	Op        Op
+	Value     any // 发送或接收的值，阻塞和关闭事件为 nil
+}
+
+// Recorder 记录一组通道上的操作
+type Recorder struct {
+	clock  atomic.Int64 // 逻辑时钟
+	mu     sync.Mutex
+	start  time.Time
+	names  map[uint64]string
+	events []Event
+}
+
+// NewRecorder 创建记录器
+func NewRecorder() *Recorder {
+	return &Recorder{start: time.Now(), names: make(map[uint64]string)}
+}
+
+// Name 为当前协程命名，之后它的操作都用这个名字显示
+func (r *Recorder) Name(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[goid()] = name
+}
+
+// Events 返回按 Seq 排列的事件
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	events := append([]Event(nil), r.events...)
+	r.mu.Unlock()
+	// 取到 Seq 与加锁追加之间可能被其他协程抢先，追加顺序不一定是 Seq 顺序
+	slices.SortFunc(events, func(a, b Event) int { return a.Seq - b.Seq })
+	return events
+}
+
+// tick 推进逻辑时钟并返回新值
+func (r *Recorder) tick() int {
+	return int(r.clock.Add(1))
+}
+
+// record 记录一个刚刚完成的操作，begin 是操作开始前的 tick()，为 0 表示瞬时事件
+func (r *Recorder) record(channel string, op Op, value any, begin int) {
+	seq := r.tick()
+	if begin == 0 {
+		begin = seq
+	}
+	id := goid()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name, ok := r.names[id]
+	if !ok {
+		name = "g" + strconv.FormatUint(id, 10)
+	}
+	r.events = append(r.events, Event{
+		Seq:       seq,
+		Begin:     begin,
+		At:        time.Since(r.start),
+		Goroutine: name,
+		Channel:   channel,
+		Op:        op,
+		Value:     value,
+	})
+}
+
+// Chan 被追踪的通道
+type Chan[T any] struct {
+	name string
+	ch   chan T
+	rec  *Recorder
+}
+
+// NewChan 创建被追踪的通道，capacity 为缓冲大小
+func NewChan[T any](rec *Recorder, name string, capacity int) *Chan[T] {
+	return &Chan[T]{name: name, ch: make(chan T, capacity), rec: rec}
+}
+
+// Send 发送 v，需要等待时先记录阻塞事件
+func (c *Chan[T]) Send(v T) {
+	begin := c.rec.tick()
+	select {
+	case c.ch <- v:
+	default:
+		c.rec.record(c.name, OpBlockSend, nil, 0)
+		c.ch <- v
+	}
+	c.rec.record(c.name, OpSend, v, begin)
+}
+
+// Recv 接收一个值，通道关闭且取空时 ok 为 false
+func (c *Chan[T]) Recv() (v T, ok bool) {
+	begin := c.rec.tick()
+	select {
+	case v, ok = <-c.ch:
+	default:
+		c.rec.record(c.name, OpBlockRecv, nil, 0)
+		v, ok = <-c.ch
+	}
+	if ok {
+		c.rec.record(c.name, OpRecv, v, begin)
+	} else {
+		c.rec.record(c.name, OpRecvClosed, nil, begin)
+	}
+	return v, ok
+}
+
+// All 返回可用于 range 的迭代器，等同于 for v := range ch
+func (c *Chan[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, ok := c.Recv()
+			if !ok || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Close 关闭通道
+func (c *Chan[T]) Close() {
+	begin := c.rec.tick()
+	close(c.ch)
+	c.rec.record(c.name, OpClose, nil, begin)
+}
+
+// Text 渲染为文本时间线，每行一个事件
+func (r *Recorder) Text() string {
+	var b strings.Builder
+	for _, e := range r.Events() {
+		fmt.Fprintf(&b, "%4d %12v  %-10s %-8s %s", e.Seq, e.At.Round(time.Microsecond), e.Goroutine, e.Channel, e.Op)
+		if e.Value != nil {
+			fmt.Fprintf(&b, " %v", e.Value)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Mermaid 渲染为 Mermaid 时序图：协程和通道各是一个参与者，
+// 发送画成协程指向通道的箭头，接收画成通道指向协程的虚线箭头，阻塞画成注释。
+// 参与者使用生成的 id（p1、p2……），名字作为别名显示，因此名字可以包含任意字符
+func (r *Recorder) Mermaid() string {
+	events := r.Events()
+
+	var b strings.Builder
+	b.WriteString("sequenceDiagram\n")
+	ids := make(map[string]string)
+	participant := func(name string) {
+		if _, ok := ids[name]; !ok {
+			ids[name] = "p" + strconv.Itoa(len(ids)+1)
+			fmt.Fprintf(&b, "    participant %s as %s\n", ids[name], mermaidText(name))
+		}
+	}
+	for _, e := range events {
+		participant(e.Goroutine)
+		participant(e.Channel)
+	}
+
+	for _, e := range events {
+		g, ch := ids[e.Goroutine], ids[e.Channel]
+		switch e.Op {
+		case OpSend:
+			fmt.Fprintf(&b, "    %s->>%s: 发送 %s\n", g, ch, mermaidText(fmt.Sprint(e.Value)))
+		case OpRecv:
+			fmt.Fprintf(&b, "    %s-->>%s: 接收 %s\n", ch, g, mermaidText(fmt.Sprint(e.Value)))
+		case OpBlockSend, OpBlockRecv:
+			fmt.Fprintf(&b, "    Note over %s: %s %s\n", g, e.Op, mermaidText(e.Channel))
+		case OpClose:
+			fmt.Fprintf(&b, "    %s-x%s: 关闭\n", g, ch)
+		case OpRecvClosed:
+			fmt.Fprintf(&b, "    %s--x%s: 已关闭\n", ch, g)
+		}
+	}
+	return b.String()
+}
+
+// mermaidEscaper 把 Mermaid 文本中有特殊含义的字符换成实体编码（#编号;），换行换成空格
+var mermaidEscaper = strings.NewReplacer(
+	"#", "#35;",
+	";", "#59;",
+	":", "#58;",
+	"<", "#60;",
+	">", "#62;",
+	"%", "#37;",
+	"\n", " ",
+	"\r", " ",
+)
+
+// mermaidText 转义参与者别名和消息文本
+func mermaidText(s string) string {
+	return mermaidEscaper.Replace(s)
+}
+
+// goid 从 runtime.Stack 的第一行 "goroutine 123 [running]:" 解析当前协程 id。
+// 协程 id 只用于调试显示，不应用于程序逻辑
+func goid() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	field := bytes.Fields(bytes.TrimPrefix(buf[:n], []byte("goroutine ")))[0]
+	id, _ := strconv.ParseUint(string(field), 10, 64)
+	return id
+}
@@ -0,0 +1,98 @@
+package chantrace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnbufferedOrdering(t *testing.T) {
+	for range 50 {
+		rec := NewRecorder()
+		ch := NewChan[int](rec, "ch", 0)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range ch.All() {
+			}
+		}()
+		for i := range 20 {
+			ch.Send(i)
+		}
+		ch.Close()
+		<-done
+
+		sends := make(map[any]Event)
+		recvs := make(map[any]Event)
+		prev := 0
+		for _, e := range rec.Events() {
+			if e.Seq <= prev {
+				t.Fatalf("events not sorted by Seq: %d after %d", e.Seq, prev)
+			}
+			prev = e.Seq
+			if e.Begin > e.Seq {
+				t.Fatalf("Begin %d > Seq %d", e.Begin, e.Seq)
+			}
+			switch e.Op {
+			case OpSend:
+				sends[e.Value] = e
+			case OpRecv:
+				recvs[e.Value] = e
+			}
+		}
+		for i := range 20 {
+			s, r := sends[i], recvs[i]
+			// 无缓冲通道上发送和接收同时生效：发送不会在接收开始之前完成，反之亦然
+			if s.Seq < r.Begin {
+				t.Fatalf("send %d completed (seq %d) before its receive began (seq %d)", i, s.Seq, r.Begin)
+			}
+			if r.Seq < s.Begin {
+				t.Fatalf("receive %d completed (seq %d) before its send began (seq %d)", i, r.Seq, s.Begin)
+			}
+		}
+	}
+}
+
+func TestBlockEvents(t *testing.T) {
+	rec := NewRecorder()
+	ch := NewChan[int](rec, "ch", 1)
+	ch.Send(1) // 有缓冲空间，不阻塞
+	ch.Recv()
+	ch.Close()
+	if _, ok := ch.Recv(); ok {
+		t.Fatal("Recv on closed channel returned ok")
+	}
+
+	var ops []Op
+	for _, e := range rec.Events() {
+		ops = append(ops, e.Op)
+	}
+	want := []Op{OpSend, OpRecv, OpClose, OpRecvClosed}
+	if len(ops) != len(want) {
+		t.Fatalf("ops = %v, want %v", ops, want)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Errorf("ops = %v, want %v", ops, want)
+			break
+		}
+	}
+}
+
+func TestMermaidEscaping(t *testing.T) {
+	rec := NewRecorder()
+	rec.Name("生产者: #1; a->>b")
+	ch := NewChan[string](rec, "in:ch%", 1)
+	ch.Send("x;y\nz")
+
+	out := rec.Mermaid()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{
+		"sequenceDiagram",
+		"    participant p1 as 生产者#58; #35;1#59; a-#62;#62;b",
+		"    participant p2 as in#58;ch#37;",
+		"    p1->>p2: 发送 x#59;y z",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Mermaid() =\n%s\nwant\n%s", out, strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,235 @@
+package five_mu
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipodone/go-homework2/lockstat"
+)
+
+// Counter 一种并发计数策略：启动 goroutines 个协程，每个协程递增 iterations 次，返回最终计数。
+// 每次调用 Count 都从 0 开始，互不影响；正确的实现总是返回 goroutines × iterations。
+type Counter interface {
+	Name() string
+	Count(goroutines, iterations int) int64
+}
+
+// Counters 返回 GetOne 到 GetFive 演示的五种策略，顺序与版本号一致
+func Counters() []Counter {
+	return []Counter{LockAroundLoop{}, LocalMutex{}, LocalAtomic{}, AtomicIncrement{}, ChannelSum{}}
+}
+
+// Result 一次计数的结果
+type Result struct {
+	Name       string
+	Goroutines int
+	Iterations int
+	Count      int64
+	Duration   time.Duration
+}
+
+// Want 返回期望的计数
+func (r Result) Want() int64 {
+	return int64(r.Goroutines) * int64(r.Iterations)
+}
+
+// OK 计数是否正确
+func (r Result) OK() bool {
+	return r.Count == r.Want()
+}
+
+// Run 用指定的协程数和每协程递增次数运行一种策略并计时
+func Run(c Counter, goroutines, iterations int) Result {
+	start := time.Now()
+	count := c.Count(goroutines, iterations)
+	return Result{
+		Name:       c.Name(),
+		Goroutines: goroutines,
+		Iterations: iterations,
+		Count:      count,
+		Duration:   time.Since(start),
+	}
+}
+
+// RunAll 依次运行多种策略
+func RunAll(goroutines, iterations int, counters ...Counter) []Result {
+	results := make([]Result, 0, len(counters))
+	for _, c := range counters {
+		results = append(results, Run(c, goroutines, iterations))
+	}
+	return results
+}
+
+// LockAroundLoop 版本1：在循环外加锁，协程之间完全串行
+type LockAroundLoop struct{}
+
+func (LockAroundLoop) Name() string { return "循环外加锁" }
+
+func (LockAroundLoop) Count(goroutines, iterations int) int64 {
+	var count int64
+	mu := lockstat.Mutex{Name: LockAroundLoop{}.Name()}
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// for循环外使用：2、defer在循环外
+			mu.Lock()
+			defer mu.Unlock()
+
+			// 在 for 循环外加锁效果：
+			// 1、串行执行：每个goroutine必须等待前一个goroutine完全执行完整个循环后才能获取锁
+			// 2、性能差：相当于串行执行 goroutines×iterations 次操作
+			// 3、无并发优势：虽然有多个goroutine，但同一时间只有一个在工作
+
+			for j := 0; j < iterations; j++ {
+				// for循环内使用锁处理，输出结果为1的原因说明如下：
+				// 关键点：defer 语句不是立即执行，而是将函数调用推迟到当前函数返回时才执行。
+				// mu.Lock()         // 第1次循环：加锁
+				// defer mu.Unlock() // 第1次循环：安排解锁（在函数返回时执行）
+				// count++           // 执行 count = 1
+
+				// 第2次循环：试图再次加锁，但锁已经被第1次的defer持有！
+				// 因为goroutine函数还没返回，defer不会执行
+				// 所以这里会死锁！（或超时后会输出1）
+
+				// 所以正确写法：1、立即解锁 2、defer在循环外 3、本地计数（推荐）
+				// 4、如果要在循环中使用defer，应该为每次迭代创建新函数（如定义线程安全计数器SafeCounter、定义方法，在此方法中使用defer）
+				// 1、立即解锁
+				// mu.Lock()
+				count++
+				// mu.Unlock()
+
+				// 黄金规则：
+				// 1、不要在循环内对同一把锁多次使用defer mu.Unlock()
+				// 2、要么在循环内立即解锁：mu.Lock(); ...; mu.Unlock()
+				// 3、要么在循环外加锁：mu.Lock(); defer mu.Unlock(); for {...}
+
+				// 在 for 循环内加锁效果：
+				// 1、高并发：所有goroutine可以同时进行，只在count++时竞争锁
+				// 2、性能好：充分利用并发优势
+				// 3、锁竞争激烈：goroutines×iterations 次锁竞争
+
+				// 加锁位置的黄金法则：
+				// 1、锁的范围应该最小化（只在必要时加锁）- 即场景2：独立计数器（锁在内或本地计数）
+				// 2、区分是否需要保护整个操作序列 - 即场景1：需要原子性的批量操作（锁在外）
+			}
+		}()
+	}
+
+	wg.Wait()
+	return count
+}
+
+// LocalMutex 版本2：本地计数，最后加锁更新一次全局计数（最佳）
+type LocalMutex struct{}
+
+func (LocalMutex) Name() string { return "本地计数+互斥锁" }
+
+func (LocalMutex) Count(goroutines, iterations int) int64 {
+	var count int64
+	mu := lockstat.Mutex{Name: LocalMutex{}.Name()}
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// 本地计数，减少锁竞争：3、本地计数（推荐）
+			localCount := int64(0)
+			for j := 0; j < iterations; j++ {
+				localCount++
+			}
+
+			// 只锁一次更新全局计数
+			mu.Lock()
+			count += localCount
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return count
+}
+
+// LocalAtomic 版本3：本地计数，最后原子累加一次
+type LocalAtomic struct{}
+
+func (LocalAtomic) Name() string { return "本地计数+原子操作" }
+
+func (LocalAtomic) Count(goroutines, iterations int) int64 {
+	var count int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			localCount := int64(0)
+			for j := 0; j < iterations; j++ {
+				localCount++
+			}
+
+			// 使用原子操作
+			atomic.AddInt64(&count, localCount) // 本地计数+原子操作
+		}() // 所以这块容易出现最常见的闭包问题：循环中变量捕获错误/意外的变量共享
+	}
+
+	wg.Wait()
+	return count
+}
+
+// AtomicIncrement 版本4：每次递增都是一次原子操作
+type AtomicIncrement struct{}
+
+func (AtomicIncrement) Name() string { return "原子递增" }
+
+func (AtomicIncrement) Count(goroutines, iterations int) int64 {
+	var count int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				atomic.AddInt64(&count, 1) // 直接使用原子操作
+			}
+		}()
+	}
+
+	wg.Wait()
+	return count
+}
+
+// ChannelSum 版本5：本地计数，通过通道把结果交给汇总方，不需要锁
+type ChannelSum struct{}
+
+func (ChannelSum) Name() string { return "本地计数+通道" }
+
+func (ChannelSum) Count(goroutines, iterations int) int64 {
+	resultCh := make(chan int64, goroutines)
+
+	// 先启动全部协程再统一接收；边启动边接收会让协程一个接一个地串行执行
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			localCount := int64(0)
+			for j := 0; j < iterations; j++ {
+				localCount++
+			}
+			resultCh <- localCount // 本地计数+通道
+		}()
+	}
+
+	var count int64
+	for i := 0; i < goroutines; i++ {
+		count += <-resultCh
+	}
+	return count
+}
@@ -0,0 +1,50 @@
+package five_mu
+
+import "testing"
+
+// testCounter 所有 Counter 实现共用的一致性测试
+func testCounter(t *testing.T, c Counter) {
+	t.Helper()
+	cases := []struct{ goroutines, iterations int }{
+		{0, 0}, {0, 100}, {1, 0}, {1, 1}, {1, 1000}, {10, 1000}, {64, 100}, {3, 7},
+	}
+	for _, tc := range cases {
+		r := Run(c, tc.goroutines, tc.iterations)
+		if !r.OK() {
+			t.Errorf("%s: Count(%d, %d) = %d, want %d", c.Name(), tc.goroutines, tc.iterations, r.Count, r.Want())
+		}
+		if r.Name != c.Name() || r.Goroutines != tc.goroutines || r.Iterations != tc.iterations {
+			t.Errorf("%s: Result 字段不匹配：%+v", c.Name(), r)
+		}
+	}
+
+	// 每次调用从 0 开始，互不影响
+	if a, b := c.Count(4, 100), c.Count(4, 100); a != b {
+		t.Errorf("%s: 两次调用结果不同：%d, %d", c.Name(), a, b)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	names := make(map[string]bool)
+	for _, c := range Counters() {
+		t.Run(c.Name(), func(t *testing.T) {
+			testCounter(t, c)
+		})
+		if names[c.Name()] {
+			t.Errorf("策略名重复：%s", c.Name())
+		}
+		names[c.Name()] = true
+	}
+}
+
+func TestRunAll(t *testing.T) {
+	results := RunAll(10, 1000, Counters()...)
+	if len(results) != len(Counters()) {
+		t.Fatalf("结果数 = %d, want %d", len(results), len(Counters()))
+	}
+	for _, r := range results {
+		if !r.OK() || r.Want() != 10000 {
+			t.Errorf("%s: count = %d, want 10000", r.Name, r.Count)
+		}
+	}
+}
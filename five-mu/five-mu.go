@@ -2,9 +2,9 @@ package five_mu
 
 import (
 	"fmt"
-	"sync"
-	"sync/atomic"
-	"time"
+	"os"
+
+	"github.com/ipodone/go-homework2/lockstat"
 )
 
 // 核心原则：锁的粒度要尽可能小，只在真正需要保护共享资源时才加锁。
@@ -17,177 +17,53 @@ import (
 
 // 题目 ：编写一个程序，使用 sync.Mutex 来保护一个共享的计数器。启动10个协程，每个协程对计数器进行1000次递增操作，最后输出计数器的值。
 // 考察点 ： sync.Mutex 的使用、并发数据安全。
-func GetOne() { // 版本1
+// 五个版本的计数策略见 counter.go，协程数和每个协程的递增次数都由调用方传入。
+func GetOne(goroutines, iterations int) { // 版本1
 	fmt.Println("=== 版本1 ===")
-
-	count := 0
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	startTime := time.Now()
-
-	for i := 1; i <= 10; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			// for循环外使用：2、defer在循环外
-			mu.Lock()
-			defer mu.Unlock()
-
-			// 在 for 循环外加锁效果：
-			// 1、串行执行：每个goroutine必须等待前一个goroutine完全执行完整个循环（10000次）后才能获取锁
-			// 2、性能差：相当于串行执行10×10000次操作
-			// 3、无并发优势：虽然有10个goroutine，但同一时间只有一个在工作
-
-			for j := 1; j <= 10000; j++ {
-				// for循环内使用锁处理，输出结果为1的原因说明如下：
-				// 关键点：defer 语句不是立即执行，而是将函数调用推迟到当前函数返回时才执行。
-				// mu.Lock()         // 第1次循环：加锁
-				// defer mu.Unlock() // 第1次循环：安排解锁（在函数返回时执行）
-				// count++           // 执行 count = 1
-
-				// 第2次循环：试图再次加锁，但锁已经被第1次的defer持有！
-				// 因为goroutine函数还没返回，defer不会执行
-				// 所以这里会死锁！（或超时后会输出1）
-
-				// 所以正确写法：1、立即解锁 2、defer在循环外 3、本地计数（推荐）
-				// 4、如果要在循环中使用defer，应该为每次迭代创建新函数（如定义线程安全计数器SafeCounter、定义方法，在此方法中使用defer）
-				// 1、立即解锁
-				// mu.Lock()
-				count++
-				// mu.Unlock()
-
-				// 黄金规则：
-				// 1、不要在循环内对同一把锁多次使用defer mu.Unlock()
-				// 2、要么在循环内立即解锁：mu.Lock(); ...; mu.Unlock()
-				// 3、要么在循环外加锁：mu.Lock(); defer mu.Unlock(); for {...}
-
-				// 在 for 循环内加锁效果：
-				// 1、高并发：所有goroutine可以同时进行，只在count++时竞争锁
-				// 2、性能好：充分利用并发优势
-				// 3、锁竞争激烈：10个goroutine×10000次=100000次锁竞争
-
-				// 加锁位置的黄金法则：
-				// 1、锁的范围应该最小化（只在必要时加锁）- 即场景2：独立计数器（锁在内或本地计数）
-				// 2、区分是否需要保护整个操作序列 - 即场景1：需要原子性的批量操作（锁在外）
-			}
-		}()
-	}
-
-	wg.Wait()
-	duration := time.Since(startTime)
-	fmt.Println("count:", count, "Time:", duration)
+	printResult(Run(LockAroundLoop{}, goroutines, iterations))
 }
 
-func GetTwo() { // 版本2：优化性能 - 减少锁竞争 - 本地计数（最佳）
+func GetTwo(goroutines, iterations int) { // 版本2：优化性能 - 减少锁竞争 - 本地计数（最佳）
 	fmt.Println("=== 版本2：优化性能（减少锁竞争）===")
-
-	count := 0
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	startTime := time.Now()
-
-	for i := 1; i <= 10; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			// 本地计数，减少锁竞争：3、本地计数（推荐）
-			localCount := 0
-			for j := 1; j <= 10000; j++ {
-				localCount++
-			}
-
-			// 只锁一次更新全局计数
-			mu.Lock()
-			count += localCount
-			mu.Unlock()
-		}()
-	}
-
-	wg.Wait()
-	duration := time.Since(startTime)
-	fmt.Println("count:", count, "Time:", duration)
+	printResult(Run(LocalMutex{}, goroutines, iterations))
 }
 
 // 题目 ：使用原子操作（ sync/atomic 包）实现一个无锁的计数器。启动10个协程，每个协程对计数器进行1000次递增操作，最后输出计数器的值。
 // 考察点 ：原子操作、并发数据安全。
-func GetThree() { // 版本3：使用原子操作 - 原子操作（更简单）
+func GetThree(goroutines, iterations int) { // 版本3：使用原子操作 - 原子操作（更简单）
 	fmt.Println("=== 版本3：使用原子操作 ===")
-
-	var count int64
-	var wg sync.WaitGroup
-
-	startTime := time.Now()
-
-	for i := 1; i <= 10; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			localCount := int64(0)
-			for j := 1; j <= 10000; j++ {
-				localCount++
-			}
-
-			// 使用原子操作
-			atomic.AddInt64(&count, localCount) // 本地计数+原子操作
-		}() // 所以这块容易出现最常见的闭包问题：循环中变量捕获错误/意外的变量共享
-	}
-
-	wg.Wait()
-	duration := time.Since(startTime)
-	fmt.Println("count:", count, "Time:", duration)
+	printResult(Run(LocalAtomic{}, goroutines, iterations))
 }
 
-func GetFour() { // 版本4：使用sync/atomic包 - 原子操作（更简单）
+func GetFour(goroutines, iterations int) { // 版本4：使用sync/atomic包 - 原子操作（更简单）
 	fmt.Println("=== 版本4：直接使用原子递增 ===")
-
-	var count int64
-	var wg sync.WaitGroup
-
-	startTime := time.Now()
-
-	for i := 1; i <= 10; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			for j := 1; j <= 10000; j++ {
-				atomic.AddInt64(&count, 1) // 直接使用原子操作
-			}
-		}()
-	}
-
-	wg.Wait()
-	duration := time.Since(startTime)
-	fmt.Println("count:", count, "Time:", duration)
+	printResult(Run(AtomicIncrement{}, goroutines, iterations))
 }
 
-func GetFive() { // 版本5：通道实现
+func GetFive(goroutines, iterations int) { // 版本5：通道实现
 	fmt.Println("=== 版本5：使用通道 ===")
+	printResult(Run(ChannelSum{}, goroutines, iterations))
+}
 
-	count := 0
-	resultCh := make(chan int, 10)
-
-	startTime := time.Now()
-
-	for i := 1; i <= 10; i++ {
-		go func() {
-			localCount := 0
-			for j := 1; j <= 10000; j++ {
-				localCount++
-			}
-			resultCh <- localCount // 本地计数+通道
-		}()
-		count += <-resultCh
+// GetSix 用 lockstat 对比锁的位置：循环外加锁、循环内加锁、本地计数后加锁一次，
+// 输出三把锁的获取次数、等待时间分布和持有时间
+func GetSix(goroutines, iterations int) {
+	fmt.Println("=== 版本6：锁位置的统计对比 ===")
+	lockstat.Reset() // 只统计本次运行
+	counters := []Counter{LockAroundLoop{}, MutexIncrement{}, LocalMutex{}}
+	for _, c := range counters {
+		printResult(Run(c, goroutines, iterations))
 	}
+	for _, c := range counters {
+		if s, ok := lockstat.Lookup(c.Name()); ok {
+			s.LongestHolder = "" // 调用栈太长，演示中省略
+			s.Print(os.Stdout)
+		}
+	}
+}
 
-	duration := time.Since(startTime)
-	fmt.Println("count:", count, "Time:", duration)
-
+func printResult(r Result) {
+	fmt.Println(r.Name, "count:", r.Count, "Time:", r.Duration)
 }
 
 // 一、原子操作
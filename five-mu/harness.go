@@ -0,0 +1,168 @@
+package five_mu
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/ipodone/go-homework2/lockstat"
+)
+
+// 统计基准工具：单次 time.Since 受调度、缓存和 GC 影响很大，不足以支撑上面的对比表。
+// Bench 对每种策略、每个 GOMAXPROCS 和协程数的组合先预热若干次，再重复测量多次，
+// 以"每次递增的纳秒数"为指标计算均值、标准差和 95% 置信区间（t 分布），
+// Markdown 按均值排名输出表格。同样的策略也以 testing.B 基准的形式提供（harness_test.go）。
+
+// BenchConfig 基准配置，零值字段使用默认值
+type BenchConfig struct {
+	Warmup     int   // 每个组合正式测量前的预热次数，默认 2
+	NoWarmup   bool  // 不预热，零值的 Warmup 表示默认值，因此单独用这个字段关闭预热
+	Trials     int   // 正式测量次数，默认 10
+	Iterations int   // 每个协程的递增次数，默认 10000
+	Goroutines []int // 协程数，默认 1、4、16、64
+	Procs      []int // GOMAXPROCS 取值，默认只用当前值
+}
+
+// withDefaults 填充未设置的字段
+func (c BenchConfig) withDefaults() BenchConfig {
+	if c.NoWarmup {
+		c.Warmup = 0
+	} else if c.Warmup <= 0 {
+		c.Warmup = 2
+	}
+	if c.Trials <= 0 {
+		c.Trials = 10
+	}
+	if c.Iterations <= 0 {
+		c.Iterations = 10000
+	}
+	if len(c.Goroutines) == 0 {
+		c.Goroutines = []int{1, 4, 16, 64}
+	}
+	if len(c.Procs) == 0 {
+		c.Procs = []int{runtime.GOMAXPROCS(0)}
+	}
+	return c
+}
+
+// Stats 一组测量值的统计量，单位为纳秒/次递增
+type Stats struct {
+	N      int
+	Mean   float64
+	StdDev float64 // 样本标准差
+	CI95   float64 // 95% 置信区间的半宽，均值 ± CI95
+}
+
+// NewStats 计算样本的统计量
+func NewStats(samples []float64) Stats {
+	s := Stats{N: len(samples)}
+	if s.N == 0 {
+		return s
+	}
+	for _, v := range samples {
+		s.Mean += v
+	}
+	s.Mean /= float64(s.N)
+	if s.N < 2 {
+		return s
+	}
+	var sq float64
+	for _, v := range samples {
+		sq += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(sq / float64(s.N-1))
+	s.CI95 = tCritical95(s.N-1) * s.StdDev / math.Sqrt(float64(s.N))
+	return s
+}
+
+// tTable 自由度 1~30 的双侧 95% t 分布临界值
+var tTable = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical95(df int) float64 {
+	if df <= len(tTable) {
+		return tTable[df-1]
+	}
+	return 1.96 // 自由度较大时近似为正态分布
+}
+
+// Measurement 一种策略在一个组合下的测量结果
+type Measurement struct {
+	Name       string
+	Procs      int
+	Goroutines int
+	Stats
+}
+
+// Bench 按配置测量各策略，返回 Procs × Goroutines × 策略 的全部结果。
+// 测量期间会临时修改 GOMAXPROCS、关闭 lockstat 统计，结束后恢复；计数错误时返回错误。
+func Bench(cfg BenchConfig, counters ...Counter) ([]Measurement, error) {
+	cfg = cfg.withDefaults()
+	prev := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(prev)
+	// 锁统计本身有开销，测量期间关闭，避免互斥锁、读写锁策略吃亏
+	defer lockstat.SetEnabled(lockstat.SetEnabled(false))
+
+	var results []Measurement
+	for _, procs := range cfg.Procs {
+		runtime.GOMAXPROCS(procs)
+		for _, g := range cfg.Goroutines {
+			for _, c := range counters {
+				for range cfg.Warmup {
+					c.Count(g, cfg.Iterations)
+				}
+				samples := make([]float64, 0, cfg.Trials)
+				for range cfg.Trials {
+					r := Run(c, g, cfg.Iterations)
+					if !r.OK() {
+						return nil, fmt.Errorf("five_mu: %s 计数错误：%d，期望 %d", r.Name, r.Count, r.Want())
+					}
+					samples = append(samples, float64(r.Duration.Nanoseconds())/float64(r.Want()))
+				}
+				results = append(results, Measurement{
+					Name:       c.Name(),
+					Procs:      procs,
+					Goroutines: g,
+					Stats:      NewStats(samples),
+				})
+			}
+		}
+	}
+	return results, nil
+}
+
+// Markdown 把测量结果渲染成 Markdown 表格：按 GOMAXPROCS、协程数分组，组内按均值从快到慢排名
+func Markdown(results []Measurement) string {
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, func(a, b Measurement) int {
+		return cmp.Or(
+			cmp.Compare(a.Procs, b.Procs),
+			cmp.Compare(a.Goroutines, b.Goroutines),
+			cmp.Compare(a.Mean, b.Mean),
+		)
+	})
+
+	var sb strings.Builder
+	sb.WriteString("| GOMAXPROCS | 协程数 | 排名 | 策略 | ns/次 | 95% 置信区间 | 标准差 | 相对最快 |\n")
+	sb.WriteString("|---:|---:|---:|---|---:|---:|---:|---:|\n")
+	rank, fastest := 0, 0.0
+	for i, m := range sorted {
+		if i == 0 || m.Procs != sorted[i-1].Procs || m.Goroutines != sorted[i-1].Goroutines {
+			rank, fastest = 0, m.Mean
+		}
+		rank++
+		relative := "-"
+		if fastest > 0 {
+			relative = fmt.Sprintf("%.2fx", m.Mean/fastest)
+		}
+		fmt.Fprintf(&sb, "| %d | %d | %d | %s | %.2f | ±%.2f | %.2f | %s |\n",
+			m.Procs, m.Goroutines, rank, m.Name, m.Mean, m.CI95, m.StdDev, relative)
+	}
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package five_mu
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ipodone/go-homework2/lockstat"
+)
+
+func TestStrategies(t *testing.T) {
+	for _, c := range BenchCounters() {
+		t.Run(c.Name(), func(t *testing.T) {
+			testCounter(t, c)
+		})
+	}
+}
+
+func TestNewStats(t *testing.T) {
+	s := NewStats([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if s.Mean != 5 {
+		t.Errorf("Mean = %v, want 5", s.Mean)
+	}
+	if want := math.Sqrt(32.0 / 7); math.Abs(s.StdDev-want) > 1e-9 {
+		t.Errorf("StdDev = %v, want %v", s.StdDev, want)
+	}
+	if want := 2.365 * s.StdDev / math.Sqrt(8); math.Abs(s.CI95-want) > 1e-9 {
+		t.Errorf("CI95 = %v, want %v", s.CI95, want)
+	}
+	if s := NewStats([]float64{3}); s.Mean != 3 || s.StdDev != 0 || s.CI95 != 0 {
+		t.Errorf("单个样本：%+v", s)
+	}
+}
+
+func TestBenchConfigDefaults(t *testing.T) {
+	if c := (BenchConfig{NoWarmup: true, Warmup: 3}).withDefaults(); c.Warmup != 0 {
+		t.Errorf("NoWarmup 时 Warmup = %d, want 0", c.Warmup)
+	}
+	if c := (BenchConfig{Warmup: 3}).withDefaults(); c.Warmup != 3 {
+		t.Errorf("Warmup 3 = %d", c.Warmup)
+	}
+	c := BenchConfig{}.withDefaults()
+	if c.Warmup != 2 || c.Trials != 10 || c.Iterations != 10000 || len(c.Goroutines) == 0 || len(c.Procs) != 1 {
+		t.Errorf("默认配置：%+v", c)
+	}
+}
+
+func TestBenchMarkdown(t *testing.T) {
+	cfg := BenchConfig{Warmup: 1, Trials: 3, Iterations: 100, Goroutines: []int{1, 4}, Procs: []int{1, 2}}
+	results, err := Bench(cfg, BenchCounters()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 * 2 * len(BenchCounters()); len(results) != want {
+		t.Fatalf("结果数 = %d, want %d", len(results), want)
+	}
+
+	md := Markdown(results)
+	lines := strings.Split(strings.TrimSpace(md), "\n")
+	if len(lines) != 2+len(results) {
+		t.Fatalf("表格行数 = %d, want %d\n%s", len(lines), 2+len(results), md)
+	}
+	// 每组的第一名相对最快为 1.00x
+	if n := strings.Count(md, "| 1.00x |"); n < 4 {
+		t.Errorf("相对最快为 1.00x 的行数 = %d, want 至少 4\n%s", n, md)
+	}
+}
+
+func BenchmarkCounters(b *testing.B) {
+	// 与 Bench 一致，关闭 lockstat 统计，只测量锁本身
+	defer lockstat.SetEnabled(lockstat.SetEnabled(false))
+	for _, c := range BenchCounters() {
+		for _, g := range []int{1, 4, 16, 64} {
+			b.Run(fmt.Sprintf("%s/goroutines=%d", c.Name(), g), func(b *testing.B) {
+				// b.N 为递增总次数：g 个协程平分，余下的 b.N%g 次由同样多的协程各递增一次
+				b.ResetTimer()
+				if per := b.N / g; per > 0 {
+					c.Count(g, per)
+				}
+				if rest := b.N % g; rest > 0 {
+					c.Count(rest, 1)
+				}
+			})
+		}
+	}
+}
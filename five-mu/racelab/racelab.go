@@ -0,0 +1,240 @@
+// Package racelab 是"丢失更新"实验：故意运行错误的计数写法，统计结果分布和丢失的更新数，
+// 让 five_mu.GetOne 注释中描述的失败真正跑出来。
+//
+// 这里的代码全部是反面教材，与 five_mu 中正确的实现刻意放在不同的包里，不要在业务代码中引用。
+// 用 -race 运行会看到数据竞争报告，这正是实验要展示的内容。
+package racelab
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipodone/go-homework2/lockorder"
+)
+
+// Variant 一种错误的计数写法。Count 在 timeout 内没有结束时返回 true；能停止的协程会先停下，
+// 返回的计数之后不再变化
+type Variant struct {
+	Name        string
+	Description string
+	Deadlocks   bool // 每次运行都会超时并泄漏被阻塞的协程，应减少运行次数
+	Count       func(goroutines, iterations int, timeout time.Duration) (count int64, timedOut bool)
+}
+
+// Variants 返回所有实验变体
+func Variants() []Variant {
+	return []Variant{
+		{
+			Name:        "无锁 count++",
+			Description: "多个协程直接执行 count++，读-改-写三步之间可能被其他协程插入（多核机器上才明显）",
+			Count:       racyIncrement,
+		},
+		{
+			Name:        "无锁 count++（放大竞争窗口）",
+			Description: "在读和写之间调用 runtime.Gosched()，单核机器上也能稳定复现丢失更新",
+			Count:       racyYield,
+		},
+		{
+			Name:        "循环内 defer Unlock",
+			Description: "defer 要到函数返回才执行，第二次循环 Lock 时锁仍被自己持有，协程永久阻塞",
+			Deadlocks:   true,
+			Count:       deferInLoop,
+		},
+	}
+}
+
+// racyIncrement 没有任何同步的 count++
+func racyIncrement(goroutines, iterations int, timeout time.Duration) (int64, bool) {
+	var count int64
+	finished, done := runWithTimeout(goroutines, timeout, func(stop *atomic.Bool) {
+		for j := 0; j < iterations && !stop.Load(); j++ {
+			count++ // 数据竞争
+		}
+	})
+	<-done // 超时后协程在下一次循环退出，等它们结束再读取计数
+	return count, !finished
+}
+
+// racyYield 与 racyIncrement 相同，但在读和写之间主动让出处理器
+func racyYield(goroutines, iterations int, timeout time.Duration) (int64, bool) {
+	var count int64
+	finished, done := runWithTimeout(goroutines, timeout, func(stop *atomic.Bool) {
+		for j := 0; j < iterations && !stop.Load(); j++ {
+			v := count // 读
+			runtime.Gosched()
+			count = v + 1 // 写：期间其他协程的递增被覆盖
+		}
+	})
+	<-done
+	return count, !finished
+}
+
+// deferInLoop 在循环内 Lock 并 defer Unlock。第一个拿到锁的协程递增一次后就在第二次 Lock 上死锁，
+// 其他协程永远等不到锁。超时后返回，被阻塞的协程无法停止，会一直泄漏到进程退出；
+// 它们都阻塞在 Lock 上，不会再修改计数。
+// 以 -tags lockdebug 构建时，lockorder 会在第二次 Lock 阻塞前报告重复加锁及两处调用栈。
+func deferInLoop(goroutines, iterations int, timeout time.Duration) (int64, bool) {
+	var count atomic.Int64 // 超时时锁仍被持有，只能用原子操作读取
+	var mu lockorder.Mutex
+	finished, _ := runWithTimeout(goroutines, timeout, func(*atomic.Bool) {
+		for j := 0; j < iterations; j++ {
+			mu.Lock()
+			defer mu.Unlock()
+			count.Add(1)
+		}
+	})
+	return count.Load(), !finished
+}
+
+// runWithTimeout 启动 goroutines 个协程执行 work，全部结束返回 true，超时时设置 stop 并返回 false。
+// done 在所有协程结束后关闭，work 检查 stop 时调用方可以等待 done 确认协程都已停止
+func runWithTimeout(goroutines int, timeout time.Duration, work func(stop *atomic.Bool)) (finished bool, done <-chan struct{}) {
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(&stop)
+		}()
+	}
+
+	all := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(all)
+	}()
+	select {
+	case <-all:
+		return true, all
+	case <-time.After(timeout):
+		stop.Store(true)
+		return false, all
+	}
+}
+
+// Report 一个变体多次运行的统计
+type Report struct {
+	Name        string
+	Description string
+	Goroutines  int
+	Iterations  int
+	Trials      int
+	Want        int64         // 正确的计数
+	Counts      map[int64]int // 最终计数 -> 出现次数
+	Wrong       int           // 按时结束但计数错误的次数
+	TimedOut    int           // 超时的次数，这些运行没有做完全部递增，不计入 Wrong 和 Lost
+	Lost        int64         // 按时结束的运行累计丢失的更新数
+	Min, Max    int64
+}
+
+// MeanLost 按时结束的运行平均每次丢失的更新数
+func (r Report) MeanLost() float64 {
+	finished := r.Trials - r.TimedOut
+	if finished == 0 {
+		return 0
+	}
+	return float64(r.Lost) / float64(finished)
+}
+
+// Run 把变体运行 trials 次并汇总结果
+func Run(v Variant, goroutines, iterations, trials int, timeout time.Duration) Report {
+	r := Report{
+		Name:        v.Name,
+		Description: v.Description,
+		Goroutines:  goroutines,
+		Iterations:  iterations,
+		Trials:      trials,
+		Want:        int64(goroutines) * int64(iterations),
+		Counts:      make(map[int64]int),
+	}
+	for i := 0; i < trials; i++ {
+		count, timedOut := v.Count(goroutines, iterations, timeout)
+		if i == 0 || count < r.Min {
+			r.Min = count
+		}
+		if i == 0 || count > r.Max {
+			r.Max = count
+		}
+		r.Counts[count]++
+		switch {
+		case timedOut:
+			r.TimedOut++
+		case count != r.Want:
+			r.Wrong++
+			r.Lost += r.Want - count
+		}
+	}
+	return r
+}
+
+// maxBuckets 分布中最多单独列出的计数值，其余合并显示
+const maxBuckets = 8
+
+// Print 输出报告：错误率、丢失的更新数和最终计数的分布（按出现次数从多到少）
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "== %s ==\n", r.Name)
+	fmt.Fprintf(w, "%s\n", r.Description)
+	fmt.Fprintf(w, "%d 个协程 × %d 次，期望 %d；运行 %d 次，错误 %d 次，超时 %d 次\n",
+		r.Goroutines, r.Iterations, r.Want, r.Trials, r.Wrong, r.TimedOut)
+	fmt.Fprintf(w, "最终计数 %d ~ %d，按时结束的运行累计丢失 %d 次更新，平均每次丢失 %.1f（%.2f%%）\n",
+		r.Min, r.Max, r.Lost, r.MeanLost(), percent(r.MeanLost(), float64(r.Want)))
+
+	counts := make([]int64, 0, len(r.Counts))
+	for c := range r.Counts {
+		counts = append(counts, c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if r.Counts[counts[i]] != r.Counts[counts[j]] {
+			return r.Counts[counts[i]] > r.Counts[counts[j]]
+		}
+		return counts[i] > counts[j]
+	})
+
+	fmt.Fprintln(w, "最终计数分布：")
+	rest := 0
+	for i, c := range counts {
+		if i >= maxBuckets {
+			rest += r.Counts[c]
+			continue
+		}
+		mark := ""
+		if c == r.Want {
+			mark = "（正确）"
+		}
+		fmt.Fprintf(w, "  %8d %s %d%s\n", c, bar(r.Counts[c], r.Trials), r.Counts[c], mark)
+	}
+	if rest > 0 {
+		fmt.Fprintf(w, "  其余 %d 种计数共 %d 次\n", len(counts)-maxBuckets, rest)
+	}
+}
+
+func percent(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+// bar 按比例绘制的条形，最长 30 格
+func bar(n, total int) string {
+	const width = 30
+	if total == 0 {
+		return ""
+	}
+	filled := max(n*width/total, 1)
+	b := make([]rune, width)
+	for i := range b {
+		if i < filled {
+			b[i] = '█'
+		} else {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
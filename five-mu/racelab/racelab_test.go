@@ -0,0 +1,122 @@
+package racelab
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scripted 依次返回给定结果的变体
+func scripted(counts []int64, timedOut []bool) Variant {
+	i := 0
+	return Variant{Name: "脚本", Count: func(int, int, time.Duration) (int64, bool) {
+		defer func() { i++ }()
+		return counts[i], timedOut[i]
+	}}
+}
+
+func TestRunReport(t *testing.T) {
+	v := scripted(
+		[]int64{100, 90, 100, 70, 5},
+		[]bool{false, false, false, false, true},
+	)
+	r := Run(v, 10, 10, 5, time.Second)
+
+	if r.Want != 100 {
+		t.Errorf("Want = %d, want 100", r.Want)
+	}
+	// 超时的运行只计入 TimedOut，丢失数只统计按时结束的 90 和 70
+	if r.Wrong != 2 || r.TimedOut != 1 || r.Lost != 40 {
+		t.Errorf("Wrong, TimedOut, Lost = %d, %d, %d, want 2, 1, 40", r.Wrong, r.TimedOut, r.Lost)
+	}
+	if r.MeanLost() != 10 {
+		t.Errorf("MeanLost() = %v, want 10", r.MeanLost())
+	}
+	if r.Min != 5 || r.Max != 100 {
+		t.Errorf("Min, Max = %d, %d, want 5, 100", r.Min, r.Max)
+	}
+	if want := map[int64]int{100: 2, 90: 1, 70: 1, 5: 1}; !reflect.DeepEqual(r.Counts, want) {
+		t.Errorf("Counts = %v, want %v", r.Counts, want)
+	}
+
+	var buf bytes.Buffer
+	r.Print(&buf)
+	lines := strings.Split(buf.String(), "\n")
+	// 分布按出现次数从多到少，正确的计数带标记
+	if i := indexOf(lines, "最终计数分布："); i < 0 || !strings.Contains(lines[i+1], "100") || !strings.Contains(lines[i+1], "（正确）") {
+		t.Errorf("Print() =\n%s\nwant the correct count listed first", buf.String())
+	}
+}
+
+func TestRunAllTimedOut(t *testing.T) {
+	r := Run(scripted([]int64{1, 1}, []bool{true, true}), 4, 3, 2, time.Second)
+	if r.Wrong != 0 || r.Lost != 0 || r.MeanLost() != 0 {
+		t.Errorf("Wrong, Lost, MeanLost = %d, %d, %v, want all 0", r.Wrong, r.Lost, r.MeanLost())
+	}
+	if (Report{}).MeanLost() != 0 {
+		t.Error("MeanLost() of an empty report != 0")
+	}
+}
+
+func TestPrintMergesBuckets(t *testing.T) {
+	counts := make([]int64, maxBuckets+3)
+	timedOut := make([]bool, len(counts))
+	for i := range counts {
+		counts[i] = int64(i)
+	}
+	r := Run(scripted(counts, timedOut), 1, 100, len(counts), time.Second)
+	var buf bytes.Buffer
+	r.Print(&buf)
+	if !strings.Contains(buf.String(), "其余 3 种计数共 3 次") {
+		t.Errorf("Print() =\n%s\nwant the last 3 counts merged", buf.String())
+	}
+}
+
+func TestDeferInLoop(t *testing.T) {
+	// 第一个拿到锁的协程递增一次后死锁，其他协程拿不到锁
+	count, timedOut := deferInLoop(4, 3, 20*time.Millisecond)
+	if !timedOut {
+		t.Error("deferInLoop finished, want a deadlock")
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+	// 只递增一次，不会死锁
+	if count, timedOut := deferInLoop(1, 1, time.Second); timedOut || count != 1 {
+		t.Errorf("deferInLoop(1, 1) = %d, %v, want 1, false", count, timedOut)
+	}
+}
+
+func TestRunWithTimeoutStops(t *testing.T) {
+	var n atomic.Int64
+	finished, done := runWithTimeout(4, 10*time.Millisecond, func(stop *atomic.Bool) {
+		for !stop.Load() {
+			n.Add(1)
+		}
+	})
+	if finished {
+		t.Fatal("finished = true, want a timeout")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("goroutines still running after stop")
+	}
+	before := n.Load()
+	time.Sleep(5 * time.Millisecond)
+	if after := n.Load(); after != before {
+		t.Errorf("count changed from %d to %d after the goroutines stopped", before, after)
+	}
+}
+
+func indexOf(lines []string, s string) int {
+	for i, l := range lines {
+		if l == s {
+			return i
+		}
+	}
+	return -1
+}
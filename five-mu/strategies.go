@@ -0,0 +1,125 @@
+package five_mu
+
+import (
+	"sync"
+
+	"github.com/ipodone/go-homework2/lockstat"
+)
+
+// 以下策略每次递增都访问共享状态，用来比较不同同步原语在竞争下的开销（见 harness.go）。
+// 与版本1~5的本地计数不同，它们对应"无法先在本地累加"的场景，例如长期存在的共享计数器。
+// 互斥锁和读写锁都使用 lockstat 的带统计版本，锁名即策略名。
+
+// BenchCounters 返回基准对比使用的策略：互斥锁、读写锁、原子操作、分片、通道
+func BenchCounters() []Counter {
+	return []Counter{MutexIncrement{}, RWMutexIncrement{}, AtomicIncrement{}, ShardedIncrement{}, ChannelIncrement{}}
+}
+
+// MutexIncrement 每次递增都在循环内加锁、立即解锁
+type MutexIncrement struct{}
+
+func (MutexIncrement) Name() string { return "互斥锁递增" }
+
+func (MutexIncrement) Count(goroutines, iterations int) int64 {
+	var count int64
+	mu := lockstat.Mutex{Name: MutexIncrement{}.Name()}
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	return count
+}
+
+// RWMutexIncrement 每次递增持写锁。纯写场景下读写锁只有额外开销，作为对照组
+type RWMutexIncrement struct{}
+
+func (RWMutexIncrement) Name() string { return "读写锁递增" }
+
+func (RWMutexIncrement) Count(goroutines, iterations int) int64 {
+	var count int64
+	mu := lockstat.RWMutex{Name: RWMutexIncrement{}.Name()}
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	mu.RLock()
+	defer mu.RUnlock()
+	return count
+}
+
+// ShardedIncrement 用分片的 StripedCounter（striped.go）递增，最后求和
+type ShardedIncrement struct{}
+
+func (ShardedIncrement) Name() string { return "分片原子递增" }
+
+func (ShardedIncrement) Count(goroutines, iterations int) int64 {
+	counter := NewStripedCounter()
+	var wg sync.WaitGroup
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				counter.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return counter.Load()
+}
+
+// ChannelIncrement 每次递增都向一个专门持有计数的协程发送消息（"通过通信来共享内存"）
+type ChannelIncrement struct{}
+
+func (ChannelIncrement) Name() string { return "通道递增" }
+
+func (ChannelIncrement) Count(goroutines, iterations int) int64 {
+	incCh := make(chan struct{}, 128)
+	result := make(chan int64)
+	go func() {
+		var count int64
+		for range incCh {
+			count++
+		}
+		result <- count
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				incCh <- struct{}{}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(incCh)
+	return <-result
+}
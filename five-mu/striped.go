@@ -0,0 +1,103 @@
+package five_mu
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// 条带计数器：本地计数（版本2、3）最快，但长期存在的共享计数器无法先在本地累加。
+// StripedCounter 把递增分散到多个单元（cell），做法与 Java 的 LongAdder 相同：
+// 按协程栈地址的哈希选单元（每个协程有自己的栈，不同协程大多落在不同单元），在该单元上 CAS；
+// CAS 失败说明有别的协程在争用同一单元，换一个哈希重试。单元之间按缓存行填充，避免相邻单元因伪共享互相使缓存失效；
+// 读取时把所有单元相加。
+//
+// 协程不绑定处理器，分散只是概率上的。只有一个处理器时只有一个单元，Add 退化为一次 atomic.Add。
+// 填充的收益只能在多核机器上看到：单核上填充、不填充的版本与直接 atomic.AddInt64 耗时相同，
+// 用 go test -bench StripedCounter 在目标机器上对比后再决定是否使用。
+//
+// 一致性：
+//   - Add 是原子的，任何一次递增都不会丢失
+//   - Load 逐个读取单元，与并发的 Add 之间没有统一的快照：结果包含 Load 开始前完成的所有 Add，
+//     并发进行的 Add 可能只被计入一部分；没有并发写入时结果精确
+//   - Reset 逐个把单元换成 0 并返回换出的总和。每次递增恰好出现在某次 Reset 的返回值
+//     或之后的 Load 中，因此"定期 Reset 并上报"不会重复或遗漏计数，
+//     但 Reset 期间并发的 Add 可能落在本次或下一次
+// 适合写多读少的统计计数（请求数、字节数）；需要"读到后立即判断"的场景（如限流阈值）请用 atomic.Int64。
+
+// cacheLine 假定的缓存行大小
+const cacheLine = 64
+
+// cell 独占一个缓存行的计数单元
+type cell struct {
+	n atomic.Int64
+	_ [cacheLine - 8]byte
+}
+
+// StripedCounter 分片计数器，零值不可用，请用 NewStripedCounter 创建
+type StripedCounter struct {
+	cells []cell
+	mask  uint64
+}
+
+// NewStripedCounter 按当前 GOMAXPROCS 创建计数器。之后调大 GOMAXPROCS 时争用会变多，结果仍然正确
+func NewStripedCounter() *StripedCounter {
+	n := stripeCount()
+	return &StripedCounter{cells: make([]cell, n), mask: uint64(n - 1)}
+}
+
+// Add 加上 delta
+func (c *StripedCounter) Add(delta int64) {
+	if c.mask == 0 {
+		c.cells[0].n.Add(delta)
+		return
+	}
+	for h := probe(); ; h = rehash(h) {
+		n := &c.cells[h&c.mask].n
+		if v := n.Load(); n.CompareAndSwap(v, v+delta) {
+			return
+		}
+	}
+}
+
+// Load 返回所有单元之和，见文件开头的一致性说明
+func (c *StripedCounter) Load() int64 {
+	var sum int64
+	for i := range c.cells {
+		sum += c.cells[i].n.Load()
+	}
+	return sum
+}
+
+// Reset 把计数清零并返回清零前的总和
+func (c *StripedCounter) Reset() int64 {
+	var sum int64
+	for i := range c.cells {
+		sum += c.cells[i].n.Swap(0)
+	}
+	return sum
+}
+
+// stripeCount 返回不小于 GOMAXPROCS 的 2 的幂
+func stripeCount() int {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	return n
+}
+
+// probe 用当前协程栈上变量的地址算出哈希。协程栈至少 2KB，去掉低位后不同协程的值不同；
+// 栈扩容搬家后哈希会变，只影响落在哪个单元
+func probe() uint64 {
+	var x byte
+	return uint64(uintptr(unsafe.Pointer(&x))>>11) * 0x9e3779b97f4a7c15 >> 32
+}
+
+// rehash 用 xorshift 换一个哈希，CAS 失败后改试其他单元
+func rehash(h uint64) uint64 {
+	h ^= h << 13
+	h ^= h >> 7
+	h ^= h << 17
+	return h
+}
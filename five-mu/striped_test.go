@@ -0,0 +1,117 @@
+package five_mu
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStripedCounter(t *testing.T) {
+	// 单处理器时只有一个单元，另外手动构造多单元的计数器覆盖 CAS 重试路径
+	t.Run("default", func(t *testing.T) { testStripedCounter(t, NewStripedCounter()) })
+	t.Run("cells=8", func(t *testing.T) { testStripedCounter(t, &StripedCounter{cells: make([]cell, 8), mask: 7}) })
+}
+
+func testStripedCounter(t *testing.T, c *StripedCounter) {
+	const goroutines, iterations = 16, 5000
+
+	// 并发递增的同时不断 Reset，每次递增恰好被计入一次
+	var reset atomic.Int64
+	stop := make(chan struct{})
+	resetDone := make(chan struct{})
+	go func() {
+		defer close(resetDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				reset.Add(c.Reset())
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				c.Add(2)
+				c.Add(-1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-resetDone
+
+	if got := reset.Load() + c.Load(); got != goroutines*iterations {
+		t.Errorf("Reset 返回值之和 + Load = %d, want %d", got, goroutines*iterations)
+	}
+	c.Reset()
+	if got := c.Load(); got != 0 {
+		t.Errorf("Reset 后 Load = %d, want 0", got)
+	}
+}
+
+// unpaddedCounter 不做填充的条带计数器，用来对比伪共享的代价
+type unpaddedCounter struct {
+	cells []atomic.Int64
+	mask  uint64
+}
+
+func newUnpaddedCounter() *unpaddedCounter {
+	n := stripeCount()
+	return &unpaddedCounter{cells: make([]atomic.Int64, n), mask: uint64(n - 1)}
+}
+
+func (c *unpaddedCounter) Add(delta int64) {
+	if c.mask == 0 {
+		c.cells[0].Add(delta)
+		return
+	}
+	for h := probe(); ; h = rehash(h) {
+		n := &c.cells[h&c.mask]
+		if v := n.Load(); n.CompareAndSwap(v, v+delta) {
+			return
+		}
+	}
+}
+
+// benchAdd 用 goroutines 个协程平分 b.N 次 add
+func benchAdd(b *testing.B, goroutines int, add func()) {
+	per := max(b.N/goroutines, 1)
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range per {
+				add()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkStripedCounter(b *testing.B) {
+	for _, g := range []int{1, 2, 4, 8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("atomic.AddInt64/goroutines=%d", g), func(b *testing.B) {
+			var n int64
+			benchAdd(b, g, func() { atomic.AddInt64(&n, 1) })
+		})
+		b.Run(fmt.Sprintf("unpadded/goroutines=%d", g), func(b *testing.B) {
+			c := newUnpaddedCounter()
+			benchAdd(b, g, func() { c.Add(1) })
+		})
+		b.Run(fmt.Sprintf("striped/goroutines=%d", g), func(b *testing.B) {
+			c := NewStripedCounter()
+			benchAdd(b, g, func() { c.Add(1) })
+		})
+	}
+}
@@ -1,14 +1,24 @@
 package four_channel
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
+
+	"github.com/ipodone/go-homework2/broker"
+	"github.com/ipodone/go-homework2/chantrace"
+	"github.com/ipodone/go-homework2/pipeline"
+	"github.com/ipodone/go-homework2/prioritychan"
 )
 
 // 题目 ：编写一个程序，使用通道实现两个协程之间的通信。一个协程生成从1到10的整数，并将这些整数发送到通道中，另一个协程从通道中接收这些整数并打印出来。
 // 考察点 ：通道的基本使用、协程间通信。
-func GetOne() {
+// 输出写入 w（两个协程共用，内部会加锁），消费者取完通道后函数立即返回，不再用 time.Sleep 等待。
+func GetOne(w io.Writer) {
+	w = &syncWriter{w: w}
+
 	// 创建无缓冲通道
 	// 无缓冲即缓冲=0，所有从发送0一开始就阻塞了。类似：ch := make(chan int, 0)
 	ch := make(chan int) // ！！！1、无缓冲通道（同步通道）// 发送和接收必须同时准备好
@@ -16,13 +26,15 @@ func GetOne() {
 	// ch := make(chan int, 2) // ！！！2、带缓冲通道（异步通道）// 可以缓冲2个元素
 	// ！！！3、单向通道
 
+	done := make(chan struct{}) // 消费者取完通道后关闭
+
 	// 第一个goroutine：生成从1到10的整数，并将这些整数发送到通道中
 	go func() {
 		defer close(ch)
 		for i := 1; i <= 10; i++ {
-			fmt.Printf("生产者: 发送前 %d\n", i)
+			fmt.Fprintf(w, "生产者: 发送前 %d\n", i)
 			ch <- i // 发送数据到通道
-			fmt.Printf("生产者: 发送后 %d\n", i)
+			fmt.Fprintf(w, "生产者: 发送后 %d\n", i)
 			// time.Sleep(1 * time.Second) // 模拟处理时间
 		}
 
@@ -30,37 +42,41 @@ func GetOne() {
 
 	// 第二个goroutine：从通道中接收这些整数并打印出来
 	go func() {
+		defer close(done)
 		for v := range ch { // range 会在通道关闭后自动退出
-			fmt.Printf("消费者: 接收 %d\n", v)
+			fmt.Fprintf(w, "消费者: 接收 %d\n", v)
 			// time.Sleep(1 * time.Second) // 模拟处理时间
 		}
 	}()
 
-	time.Sleep(3 * time.Second)
+	// time.Sleep(3 * time.Second) // 固定等待：总是耗时3秒，机器慢时还可能截断输出
+	<-done
 }
 
-func sendOnly(ch chan<- int, wg *sync.WaitGroup) {
+func sendOnly(ch chan<- int, wg *sync.WaitGroup, w io.Writer) {
 	defer wg.Done()
 	defer close(ch)
 	for i := 1; i <= 100; i++ {
-		fmt.Println("生产前：", i)
+		fmt.Fprintln(w, "生产前：", i)
 		ch <- i
-		fmt.Println("生产后：", i)
+		fmt.Fprintln(w, "生产后：", i)
 	}
 
 }
 
-func receiveOnly(ch <-chan int, wg *sync.WaitGroup) {
+func receiveOnly(ch <-chan int, wg *sync.WaitGroup, w io.Writer) {
 	defer wg.Done()
 	for v := range ch {
-		fmt.Println("消费：", v)
+		fmt.Fprintln(w, "消费：", v)
 	}
 
 }
 
 // 题目 ：实现一个带有缓冲的通道，生产者协程向通道中发送100个整数，消费者协程从通道中接收这些整数并打印。
 // 考察点 ：指针运算、切片操作。
-func GetTwo() {
+func GetTwo(w io.Writer) {
+	w = &syncWriter{w: w}
+
 	// 单向通道（更安全的设计）
 	// 创建一个双向通道
 	ch := make(chan int, 100)
@@ -68,13 +84,179 @@ func GetTwo() {
 	wg.Add(2)
 
 	// 仅发送/生产
-	go sendOnly(ch, &wg)
-
-	time.Sleep(1 * time.Microsecond)
+	go sendOnly(ch, &wg, w)
 
-	// 仅接收/消费
-	go receiveOnly(ch, &wg)
+	// 仅接收/消费（无需等生产者先启动，通道会协调两者）
+	go receiveOnly(ch, &wg, w)
 
 	wg.Wait()
 
 }
+
+// syncWriter 让多个协程可以安全地写同一个 io.Writer
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// GetThree 用泛型流水线把 sendOnly/receiveOnly 推广为多阶段：
+// 生产 1~20 → 4 个协程并行求平方（保持顺序）→ 消费打印
+func GetThree() {
+	p := pipeline.New(context.Background())
+
+	nums := pipeline.Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 1; i <= 20; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+		return nil
+	})
+	squares := pipeline.ParallelMap(p, nums, 4, func(ctx context.Context, v int) (int, error) {
+		time.Sleep(time.Duration(20-v) * time.Millisecond) // 越小的数处理越慢，但输出仍按顺序
+		return v * v, nil
+	})
+	pipeline.Sink(p, squares, func(ctx context.Context, v int) error {
+		fmt.Println("消费：", v)
+		return nil
+	})
+
+	if err := p.Wait(); err != nil {
+		fmt.Println("流水线出错：", err)
+	}
+}
+
+// GetFour 用被追踪的通道重跑 GetOne 的生产者/消费者，输出 Mermaid 时序图。
+// 图中可以看到：无缓冲通道上生产者先"阻塞 发送"，直到消费者接收后发送才完成。
+func GetFour(w io.Writer) {
+	rec := chantrace.NewRecorder()
+	ch := chantrace.NewChan[int](rec, "ch", 0)
+	done := make(chan struct{})
+
+	go func() {
+		rec.Name("生产者")
+		defer ch.Close()
+		for i := 1; i <= 3; i++ {
+			ch.Send(i)
+		}
+	}()
+
+	go func() {
+		rec.Name("消费者")
+		defer close(done)
+		for range ch.All() {
+		}
+	}()
+
+	<-done
+	fmt.Fprint(w, rec.Mermaid())
+}
+
+// GetFive 演示进程内发布/订阅：通配符订阅，以及四种慢消费者策略。
+// 每个订阅者缓冲为 2，且在发布期间都不消费，发布 5 条消息后对比各策略的结果。
+func GetFive(w io.Writer) {
+	b := broker.New()
+	defer b.Close()
+
+	// 通配符："*" 匹配一层，"#" 匹配零层或多层
+	patterns := []string{"order.*", "order.#", "*.paid"}
+	topics := []string{"order", "order.created", "order.item.added", "user.paid"}
+	subs := make([]*broker.Subscription, len(patterns))
+	for i, pattern := range patterns {
+		subs[i], _ = b.Subscribe(pattern, len(topics), broker.DropNewest)
+	}
+	for _, topic := range topics {
+		b.Publish(topic, nil)
+	}
+	for _, sub := range subs {
+		fmt.Fprintf(w, "%-8s 收到：", sub.Pattern())
+		for range len(sub.C) {
+			fmt.Fprint(w, " ", (<-sub.C).Topic)
+		}
+		fmt.Fprintln(w)
+		sub.Unsubscribe()
+	}
+
+	// 慢消费者策略
+	policies := []struct {
+		name   string
+		policy broker.Policy
+	}{
+		{"DropOldest", broker.DropOldest},
+		{"DropNewest", broker.DropNewest},
+		{"Block", broker.Block},
+		{"Disconnect", broker.Disconnect},
+	}
+	const buffer = 2
+	for _, p := range policies {
+		sub, _ := b.Subscribe("tick", buffer, p.policy)
+
+		published := make(chan struct{})
+		filled := make(chan struct{}) // 发布者已填满缓冲，之后的发布会触发慢消费者策略
+		go func() {
+			defer close(published)
+			for i := 1; i <= 5; i++ {
+				b.Publish("tick", i)
+				if i == buffer {
+					close(filled)
+				}
+			}
+		}()
+
+		var got []any
+		switch p.policy {
+		case broker.Block:
+			// 缓冲满后发布者阻塞，开始消费后发布者随之继续
+			<-filled
+			for range 5 {
+				got = append(got, (<-sub.C).Payload)
+			}
+			<-published
+		case broker.Disconnect:
+			// 断开后 C 被关闭，range 取完缓冲中的消息后结束
+			<-published
+			for msg := range sub.C {
+				got = append(got, msg.Payload)
+			}
+		default:
+			<-published
+			for range len(sub.C) {
+				got = append(got, (<-sub.C).Payload)
+			}
+		}
+		m := sub.Metrics()
+		fmt.Fprintf(w, "%-10s 收到 %v，丢弃 %d，断开 %v\n", p.name, got, m.Dropped, m.Disconnected)
+		sub.Unsubscribe()
+	}
+}
+
+// GetSix 演示多车道优先级通道：告警、普通请求、批量任务三条车道。
+// 先严格按优先级接收，再打开饥饿保护，对比批量任务被服务的时机。
+func GetSix(w io.Writer) {
+	names := []string{"告警", "请求", "批量"}
+	for _, guard := range []int{0, 2} {
+		c := prioritychan.New[string](len(names), 8).WithStarvationGuard(guard)
+
+		// 先放入全部消息再开始接收，接收顺序只取决于优先级：批量任务先到，随后是一串请求和一条告警。
+		// 每条车道容量为 8，放入不会阻塞
+		c.Send(2, "批量1")
+		c.Send(2, "批量2")
+		for i := 1; i <= 4; i++ {
+			c.Send(1, fmt.Sprint("请求", i))
+		}
+		c.Send(0, "告警1")
+		c.Close()
+
+		fmt.Fprintf(w, "饥饿保护 %d：", guard)
+		for lane, v := range c.All() {
+			fmt.Fprintf(w, " %s(%s)", v, names[lane])
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,117 @@
+package four_channel
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lineIndex 返回每一行在输出中的位置，行重复时测试失败
+func lineIndex(t *testing.T, out string) map[string]int {
+	t.Helper()
+	index := make(map[string]int)
+	for i, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if _, ok := index[line]; ok {
+			t.Fatalf("重复的输出行 %q", line)
+		}
+		index[line] = i
+	}
+	return index
+}
+
+func TestGetOne(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Now()
+	GetOne(&buf)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("GetOne() 耗时 %v，应在消费者取完通道后立即返回", d)
+	}
+
+	index := lineIndex(t, buf.String())
+	if len(index) != 30 {
+		t.Fatalf("GetOne() 输出 %d 行，want 30:\n%s", len(index), buf.String())
+	}
+
+	at := func(format string, i int) int {
+		line := fmt.Sprintf(format, i)
+		pos, ok := index[line]
+		if !ok {
+			t.Fatalf("缺少输出行 %q", line)
+		}
+		return pos
+	}
+
+	// 无缓冲通道保证的顺序：
+	// 1. 发送 i 之前先输出"发送前 i"，接收到 i 之后才输出"接收 i"
+	// 2. 消费者输出"接收 i"后才去接收 i+1，而发送 i+1 要等接收方就绪才能完成，
+	//    所以"接收 i"一定在"发送后 i+1"之前
+	for i := 1; i <= 10; i++ {
+		if at("生产者: 发送前 %d", i) > at("消费者: 接收 %d", i) {
+			t.Errorf("\"接收 %d\" 出现在 \"发送前 %d\" 之前", i, i)
+		}
+		if i > 1 && at("消费者: 接收 %d", i-1) > at("生产者: 发送后 %d", i) {
+			t.Errorf("\"发送后 %d\" 出现在 \"接收 %d\" 之前", i, i-1)
+		}
+		if i > 1 && at("消费者: 接收 %d", i-1) > at("消费者: 接收 %d", i) {
+			t.Errorf("接收顺序错误：%d 在 %d 之后", i-1, i)
+		}
+	}
+}
+
+func TestGetTwo(t *testing.T) {
+	var buf bytes.Buffer
+	GetTwo(&buf)
+
+	index := lineIndex(t, buf.String())
+	if len(index) != 300 {
+		t.Fatalf("GetTwo() 输出 %d 行，want 300", len(index))
+	}
+
+	prev := -1
+	for i := 1; i <= 100; i++ {
+		received, ok := index[fmt.Sprint("消费： ", i)]
+		if !ok {
+			t.Fatalf("缺少 \"消费： %d\"", i)
+		}
+		if sent := index[fmt.Sprint("生产前： ", i)]; sent > received {
+			t.Errorf("\"消费： %d\" 出现在 \"生产前： %d\" 之前", i, i)
+		}
+		if received < prev {
+			t.Errorf("接收顺序错误：%d", i)
+		}
+		prev = received
+	}
+}
+
+func TestGetFive(t *testing.T) {
+	var buf bytes.Buffer
+	GetFive(&buf)
+
+	want := []string{
+		"order.*  收到： order.created",
+		"order.#  收到： order order.created order.item.added",
+		"*.paid   收到： user.paid",
+		"DropOldest 收到 [4 5]，丢弃 3，断开 false",
+		"DropNewest 收到 [1 2]，丢弃 3，断开 false",
+		"Block      收到 [1 2 3 4 5]，丢弃 0，断开 false",
+		"Disconnect 收到 [1 2]，丢弃 1，断开 true",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("GetFive() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGetSix(t *testing.T) {
+	var buf bytes.Buffer
+	GetSix(&buf)
+
+	want := []string{
+		"饥饿保护 0： 告警1(告警) 请求1(请求) 请求2(请求) 请求3(请求) 请求4(请求) 批量1(批量) 批量2(批量)",
+		"饥饿保护 2： 告警1(告警) 请求1(请求) 批量1(批量) 请求2(请求) 请求3(请求) 批量2(批量) 请求4(请求)",
+	}
+	if got := strings.TrimSpace(buf.String()); got != strings.Join(want, "\n") {
+		t.Errorf("GetSix() =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,373 @@
+//go:build lockdebug
+
+package lockorder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Enabled 是否以 lockdebug 标签构建（检测是否开启）
+const Enabled = true
+
+// Mutex 带锁顺序检测的互斥锁
+type Mutex struct {
+	mu   sync.Mutex
+	node node
+}
+
+// Lock 检查顺序后加锁
+func (m *Mutex) Lock() {
+	id := m.node.init(m)
+	stack := before(id)
+	m.mu.Lock()
+	after(id, stack)
+}
+
+// TryLock 尝试加锁。不会阻塞，因此不参与顺序检查，成功时仍记录为已持有
+func (m *Mutex) TryLock() bool {
+	id := m.node.init(m)
+	if !m.mu.TryLock() {
+		return false
+	}
+	after(id, callers())
+	return true
+}
+
+// Unlock 解锁
+func (m *Mutex) Unlock() {
+	release(m.node.id)
+	m.mu.Unlock()
+}
+
+// RWMutex 带锁顺序检测的读写锁，读锁和写锁都参与顺序检查
+type RWMutex struct {
+	mu   sync.RWMutex
+	node node
+}
+
+func (m *RWMutex) Lock() {
+	id := m.node.init(m)
+	stack := before(id)
+	m.mu.Lock()
+	after(id, stack)
+}
+
+func (m *RWMutex) TryLock() bool {
+	id := m.node.init(m)
+	if !m.mu.TryLock() {
+		return false
+	}
+	after(id, callers())
+	return true
+}
+
+func (m *RWMutex) Unlock() {
+	release(m.node.id)
+	m.mu.Unlock()
+}
+
+// RLock 有写者在等待时，同一协程递归加读锁也会死锁，因此同样检查
+func (m *RWMutex) RLock() {
+	id := m.node.init(m)
+	stack := before(id)
+	m.mu.RLock()
+	after(id, stack)
+}
+
+func (m *RWMutex) TryRLock() bool {
+	id := m.node.init(m)
+	if !m.mu.TryRLock() {
+		return false
+	}
+	after(id, callers())
+	return true
+}
+
+func (m *RWMutex) RUnlock() {
+	release(m.node.id)
+	m.mu.RUnlock()
+}
+
+// RLocker 返回以读锁实现的 sync.Locker，与 sync.RWMutex.RLocker 相同
+func (m *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(m)
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock()   { (*RWMutex)(r).RLock() }
+func (r *rlocker) Unlock() { (*RWMutex)(r).RUnlock() }
+
+// node 锁在顺序图中的编号，第一次加锁时分配
+type node struct {
+	once sync.Once
+	id   uint64
+}
+
+// init 分配编号并登记顶点。图中只保存编号，不引用锁本身，锁被回收后由 cleanup 删除顶点和相关的边，
+// 大量按对象创建的锁不会让图无限增长
+func (n *node) init(lock any) uint64 {
+	n.once.Do(func() {
+		mu.Lock()
+		nextID++
+		n.id = nextID
+		graph[n.id] = &vertex{
+			name: fmt.Sprintf("%s(%p)", callerName(), lock),
+			out:  make(map[uint64]edgeInfo),
+			in:   make(map[uint64]struct{}),
+		}
+		mu.Unlock()
+		switch l := lock.(type) {
+		case *Mutex:
+			runtime.AddCleanup(l, forget, n.id)
+		case *RWMutex:
+			runtime.AddCleanup(l, forget, n.id)
+		}
+	})
+	return n.id
+}
+
+// vertex 顺序图中的一把锁
+type vertex struct {
+	name    string
+	out     map[uint64]edgeInfo // 持有本锁时获取过的锁
+	in      map[uint64]struct{} // 持有哪些锁时获取过本锁，删除顶点时用来清理指向它的边
+	relocks map[string]bool     // 已报告过的重复加锁位置
+}
+
+// held 协程持有的一把锁
+type held struct {
+	id    uint64
+	stack string
+}
+
+// edgeInfo 一条顺序边第一次出现时的调用栈
+type edgeInfo struct {
+	heldStack, takeStack string
+}
+
+var (
+	mu      sync.Mutex
+	nextID  uint64
+	graph   = make(map[uint64]*vertex) // 锁编号 -> 顶点
+	holding = make(map[uint64][]held)  // 协程 -> 按获取顺序持有的锁
+	handler = func(r Report) { fmt.Fprint(os.Stderr, r.String()) }
+)
+
+// SetHandler 设置报告的处理函数，nil 恢复为写到标准错误
+func SetHandler(h func(Report)) {
+	mu.Lock()
+	defer mu.Unlock()
+	if h == nil {
+		h = func(r Report) { fmt.Fprint(os.Stderr, r.String()) }
+	}
+	handler = h
+}
+
+// before 在真正加锁（可能阻塞）之前检查重复加锁和顺序环，返回本次加锁的调用栈
+func before(id uint64) string {
+	stack := callers()
+	gid := goid()
+
+	var reports []Report
+	mu.Lock()
+	v := graph[id]
+	for _, h := range holding[gid] {
+		if h.id == id {
+			if !v.relocks[stack] {
+				if v.relocks == nil {
+					v.relocks = make(map[string]bool)
+				}
+				v.relocks[stack] = true
+				reports = append(reports, Report{Kind: Relock, Edges: []Edge{{
+					From: v.name, To: v.name, HeldStack: h.stack, TakeStack: stack,
+				}}})
+			}
+			continue
+		}
+		hv := graph[h.id]
+		if _, ok := hv.out[id]; ok {
+			continue
+		}
+		// 新边 h -> n：如果 n 已经能到达 h，就形成了环。每条边只添加一次，因此同一个环只报告一次
+		if path := findPath(id, h.id); path != nil {
+			reports = append(reports, cycleReport(path, Edge{
+				From: hv.name, To: v.name, HeldStack: h.stack, TakeStack: stack,
+			}))
+		}
+		hv.out[id] = edgeInfo{heldStack: h.stack, takeStack: stack}
+		v.in[h.id] = struct{}{}
+	}
+	h := handler
+	mu.Unlock()
+
+	for _, r := range reports {
+		h(r)
+	}
+	return stack
+}
+
+// after 加锁成功后记录为当前协程持有
+func after(id uint64, stack string) {
+	gid := goid()
+	mu.Lock()
+	defer mu.Unlock()
+	holding[gid] = append(holding[gid], held{id: id, stack: stack})
+}
+
+// release 解锁前从持有列表中移除。Go 允许由其他协程解锁，当前协程没有持有时在所有协程中查找
+func release(id uint64) {
+	gid := goid()
+	mu.Lock()
+	defer mu.Unlock()
+	if remove(gid, id) {
+		return
+	}
+	for g := range holding {
+		if remove(g, id) {
+			return
+		}
+	}
+}
+
+// remove 移除协程 gid 最近一次获取的 id（调用方持有 mu）
+func remove(gid uint64, id uint64) bool {
+	hs := holding[gid]
+	for i := len(hs) - 1; i >= 0; i-- {
+		if hs[i].id == id {
+			hs = append(hs[:i], hs[i+1:]...)
+			if len(hs) == 0 {
+				delete(holding, gid)
+			} else {
+				holding[gid] = hs
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// forget 锁被回收后删除它的顶点、相关的边和残留的持有记录
+func forget(id uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := graph[id]
+	if !ok {
+		return
+	}
+	for to := range v.out {
+		delete(graph[to].in, id)
+	}
+	for from := range v.in {
+		delete(graph[from].out, id)
+	}
+	delete(graph, id)
+	for g := range holding {
+		for remove(g, id) {
+		}
+	}
+}
+
+// findPath 在顺序图中广度优先查找 from 到 to 的路径，返回途经的节点（含两端），不可达时返回 nil（调用方持有 mu）
+func findPath(from, to uint64) []uint64 {
+	prev := map[uint64]uint64{from: 0} // 编号从 1 开始，0 表示没有前驱
+	queue := []uint64{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == to {
+			var path []uint64
+			for n := to; n != 0; n = prev[n] {
+				path = append([]uint64{n}, path...)
+			}
+			return path
+		}
+		for next := range graph[cur].out {
+			if _, seen := prev[next]; !seen {
+				prev[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// cycleReport 由已有路径 path（n ... h）和新边 h -> n 组成环的报告（调用方持有 mu）
+func cycleReport(path []uint64, added Edge) Report {
+	r := Report{Kind: Cycle}
+	for i := 0; i+1 < len(path); i++ {
+		from, to := graph[path[i]], graph[path[i+1]]
+		info := from.out[path[i+1]]
+		r.Edges = append(r.Edges, Edge{
+			From: from.name, To: to.name,
+			HeldStack: info.heldStack, TakeStack: info.takeStack,
+		})
+	}
+	r.Edges = append(r.Edges, added)
+	return r
+}
+
+// thisFile 本文件的路径，调用栈中本文件的帧都是检测器自身的，不属于调用方。
+// 按文件而不是包名过滤，lockorder 自己的测试函数仍然保留在调用栈中
+var thisFile = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return file
+}()
+
+// callers 返回调用方（lockorder 之外）的调用栈
+func callers() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var sb strings.Builder
+	for {
+		f, more := frames.Next()
+		if f.File != thisFile {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// callerName lockorder 之外第一个调用方的代码位置
+func callerName() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if f.File != thisFile && !strings.HasPrefix(f.Function, "sync.") {
+			file := f.File
+			if i := strings.LastIndex(file, "/"); i >= 0 {
+				if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+					file = file[j+1:]
+				}
+			}
+			return file + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// goid 从 runtime.Stack 的首行 "goroutine 123 [running]:" 解析当前协程 ID，只在调试模式下使用
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
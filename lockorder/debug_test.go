@@ -0,0 +1,160 @@
+//go:build lockdebug
+
+package lockorder
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collect 在测试期间收集报告
+func collect(t *testing.T) func() []Report {
+	var (
+		rmu     sync.Mutex
+		reports []Report
+	)
+	SetHandler(func(r Report) {
+		rmu.Lock()
+		defer rmu.Unlock()
+		reports = append(reports, r)
+	})
+	t.Cleanup(func() { SetHandler(nil) })
+	return func() []Report {
+		rmu.Lock()
+		defer rmu.Unlock()
+		return append([]Report(nil), reports...)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	reports := collect(t)
+	var a, b Mutex
+	a.Lock()
+	b.Lock()
+	b.Unlock()
+	a.Unlock()
+	if n := len(reports()); n != 0 {
+		t.Fatalf("%d reports for a consistent order", n)
+	}
+
+	// 另一个协程以相反顺序加锁，这次没有真正死锁也要报告
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Lock()
+		a.Lock()
+		a.Unlock()
+		b.Unlock()
+	}()
+	<-done
+
+	rs := reports()
+	if len(rs) != 1 || rs[0].Kind != Cycle {
+		t.Fatalf("reports = %v, want one Cycle", rs)
+	}
+	if len(rs[0].Edges) != 2 || rs[0].Edges[0].From != rs[0].Edges[1].To {
+		t.Errorf("cycle edges = %+v, want a -> b -> a", rs[0].Edges)
+	}
+	if !strings.Contains(rs[0].String(), "TestCycle") {
+		t.Errorf("report does not contain the locking stacks:\n%s", rs[0])
+	}
+
+	// 同一个环不重复报告
+	b.Lock()
+	a.Lock()
+	a.Unlock()
+	b.Unlock()
+	if n := len(reports()); n != 1 {
+		t.Errorf("%d reports after repeating the order, want 1", n)
+	}
+}
+
+func TestRelock(t *testing.T) {
+	reports := collect(t)
+	var m Mutex
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Lock()
+		m.Lock() // 在阻塞之前报告
+		m.Unlock()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(reports()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("relock not reported")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Unlock() // 由其他协程解锁，放行被阻塞的第二次 Lock
+	<-done
+
+	rs := reports()
+	if len(rs) != 1 || rs[0].Kind != Relock {
+		t.Fatalf("reports = %v, want one Relock", rs)
+	}
+	if e := rs[0].Edges[0]; e.From != e.To || e.HeldStack == e.TakeStack {
+		t.Errorf("relock edge = %+v, want the same lock with two different stacks", e)
+	}
+}
+
+func TestRLocker(t *testing.T) {
+	reports := collect(t)
+	var m RWMutex
+	l := m.RLocker()
+	l.Lock()
+	if m.TryLock() {
+		t.Error("TryLock succeeded while a read lock is held")
+	}
+	l.Unlock()
+	m.Lock()
+	m.Unlock()
+	if n := len(reports()); n != 0 {
+		t.Errorf("%d unexpected reports", n)
+	}
+}
+
+// graphSize 顺序图中的顶点数和边数
+func graphSize() (vertices, edges int) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range graph {
+		edges += len(v.out)
+	}
+	return len(graph), edges
+}
+
+func TestGraphForgetsCollectedLocks(t *testing.T) {
+	collect(t)
+	var outer Mutex
+	outer.Lock()
+	outer.Unlock()
+	baseV, baseE := graphSize()
+
+	// 按对象创建的锁：持有 outer 时获取，每把锁新增一个顶点和一条边
+	for range 1000 {
+		m := new(Mutex)
+		outer.Lock()
+		m.Lock()
+		m.Unlock()
+		outer.Unlock()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runtime.GC()
+		v, e := graphSize()
+		if v <= baseV && e <= baseE {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("graph still has %d vertices and %d edges after GC, want %d and %d", v, e, baseV, baseE)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	runtime.KeepAlive(&outer)
+}
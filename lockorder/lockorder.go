@@ -0,0 +1,99 @@
+// Package lockorder 是运行时的锁顺序死锁检测器。
+//
+// Mutex、RWMutex 可以直接替换 sync.Mutex、sync.RWMutex。
+// 默认构建时它们只是 sync 的同名类型嵌入一层，没有任何额外开销；
+// 用 -tags lockdebug 构建时，每次加锁都会记录"持有 A 时获取 B"这样的顺序，
+// 在所有协程之间建立一张锁获取顺序图：
+//   - 新的顺序边让图中出现环（例如一个协程 A→B，另一个协程 B→A）时，报告潜在的死锁，
+//     附上环上每条边第一次出现时两次加锁的调用栈。即使这次运行没有真正死锁，也会报告
+//   - 同一个协程再次获取自己已持有的锁（例如循环内 defer Unlock），在阻塞之前报告重复加锁
+//
+// 报告默认写到标准错误，可以用 SetHandler 替换（例如在测试中让报告变成失败）。
+// 锁以第一次加锁的代码位置和地址命名。锁被垃圾回收后，它在顺序图中的节点和边随之删除。
+package lockorder
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 两种构建下 Mutex、RWMutex 的方法集相同，切换标签不会让调用方编译失败
+var (
+	_ interface {
+		sync.Locker
+		TryLock() bool
+	} = (*Mutex)(nil)
+	_ interface {
+		sync.Locker
+		TryLock() bool
+		RLock()
+		RUnlock()
+		TryRLock() bool
+		RLocker() sync.Locker
+	} = (*RWMutex)(nil)
+)
+
+// Kind 问题类型
+type Kind int
+
+const (
+	Cycle  Kind = iota // 锁获取顺序形成环
+	Relock             // 同一协程重复获取已持有的锁
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Cycle:
+		return "锁顺序环"
+	case Relock:
+		return "重复加锁"
+	}
+	return "未知"
+}
+
+// Edge 顺序图中的一条边：持有 From 时获取了 To
+type Edge struct {
+	From, To  string
+	HeldStack string // 获取 From 时的调用栈
+	TakeStack string // 持有 From 时获取 To 的调用栈
+}
+
+// Report 一次检测到的问题
+type Report struct {
+	Kind Kind
+	// Cycle：环上的边，依次首尾相连，最后一条是本次新增的边
+	// Relock：只有一条边，From 与 To 是同一把锁，两个调用栈分别是第一次和再次加锁的位置
+	Edges []Edge
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	switch r.Kind {
+	case Cycle:
+		names := make([]string, 0, len(r.Edges)+1)
+		for _, e := range r.Edges {
+			names = append(names, e.From)
+		}
+		names = append(names, r.Edges[0].From)
+		fmt.Fprintf(&sb, "lockorder: 可能的死锁，锁获取顺序形成环：%s\n", strings.Join(names, " -> "))
+		for _, e := range r.Edges {
+			fmt.Fprintf(&sb, "  %s -> %s\n", e.From, e.To)
+			writeStack(&sb, "持有 "+e.From+" 的加锁位置", e.HeldStack)
+			writeStack(&sb, "获取 "+e.To+" 的位置", e.TakeStack)
+		}
+	case Relock:
+		e := r.Edges[0]
+		fmt.Fprintf(&sb, "lockorder: 协程重复获取已持有的锁 %s，将永久阻塞\n", e.From)
+		writeStack(&sb, "第一次加锁", e.HeldStack)
+		writeStack(&sb, "再次加锁", e.TakeStack)
+	}
+	return sb.String()
+}
+
+func writeStack(sb *strings.Builder, title, stack string) {
+	fmt.Fprintf(sb, "    %s：\n", title)
+	for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+		fmt.Fprintf(sb, "      %s\n", line)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !lockdebug
+
+package lockorder
+
+import "sync"
+
+// Enabled 是否以 lockdebug 标签构建（检测是否开启）
+const Enabled = false
+
+// Mutex 未开启检测时就是 sync.Mutex
+type Mutex struct {
+	sync.Mutex
+}
+
+// RWMutex 未开启检测时就是 sync.RWMutex
+type RWMutex struct {
+	sync.RWMutex
+}
+
+// SetHandler 设置报告的处理函数，未开启检测时不会有报告
+func SetHandler(func(Report)) {}
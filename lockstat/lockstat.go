@@ -0,0 +1,317 @@
+package lockstat
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 带统计的锁：Mutex、RWMutex 可以直接替换 sync.Mutex、sync.RWMutex（零值可用，不可复制），
+// 按锁的名字汇总以下数据，用来找出代码里哪把锁最热：
+//   - 获取锁的等待时间：次数、总和、最大值和按数量级分桶的直方图
+//   - 持有时间（写锁）：次数、总和、最大值，以及持有最久的那一次解锁时的调用栈
+//     （只在持有时间创新高时采集，平时加锁解锁不遍历调用栈；用 defer 解锁时栈中包含加锁的函数）
+//   - 当前正在等待的协程数
+// Name 为空的锁以第一次加锁的代码位置（文件:行号）命名。同名的锁共用一份统计。
+//
+// 读锁只统计等待时间：多个读者同时持有，锁本身无法区分各自的持有时间。
+// SetEnabled(false) 后只剩一次原子读的开销，用于不希望统计干扰测量的场景（如 five_mu 的基准）。
+
+var enabled atomic.Bool
+
+func init() {
+	enabled.Store(true)
+}
+
+// SetEnabled 开启或关闭统计，返回之前的状态
+func SetEnabled(on bool) bool {
+	return enabled.Swap(on)
+}
+
+// Buckets 等待时间直方图各桶的上界，最后一个桶没有上界
+var Buckets = [...]time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram 等待时间直方图，Histogram[i] 为落在 (Buckets[i-1], Buckets[i]] 的次数，最后一个为超过 1s 的次数
+type Histogram [len(Buckets) + 1]uint64
+
+// Stats 一个名字下所有锁的统计
+type Stats struct {
+	Name string
+
+	Acquisitions     uint64 // 写锁获取次数
+	ReadAcquisitions uint64 // 读锁获取次数
+	Contended        uint64 // 需要等待的获取次数（读写合计）
+	WaitTotal        time.Duration
+	WaitMax          time.Duration
+	WaitHistogram    Histogram
+
+	HoldTotal     time.Duration // 写锁持有时间总和
+	HoldMax       time.Duration
+	LongestHolder string // 持有最久的那次解锁时的调用栈
+
+	Waiters int64 // 当前正在等待的协程数
+}
+
+// WaitMean 平均等待时间
+func (s Stats) WaitMean() time.Duration {
+	if n := s.Acquisitions + s.ReadAcquisitions; n > 0 {
+		return s.WaitTotal / time.Duration(n)
+	}
+	return 0
+}
+
+// HoldMean 写锁平均持有时间
+func (s Stats) HoldMean() time.Duration {
+	if s.Acquisitions > 0 {
+		return s.HoldTotal / time.Duration(s.Acquisitions)
+	}
+	return 0
+}
+
+// record 一个名字的统计，计数都是原子变量
+type record struct {
+	name             string
+	acquisitions     atomic.Uint64
+	readAcquisitions atomic.Uint64
+	contended        atomic.Uint64
+	waitTotal        atomic.Int64
+	waitMax          atomic.Int64
+	histogram        [len(Buckets) + 1]atomic.Uint64
+	holdTotal        atomic.Int64
+	holdMax          atomic.Int64
+	waiters          atomic.Int64
+
+	mu      sync.Mutex
+	longest []uintptr
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*record)
+)
+
+func lookup(name string) *record {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	r, ok := registry[name]
+	if !ok {
+		r = &record{name: name}
+		registry[name] = r
+	}
+	return r
+}
+
+func (r *record) wait(d time.Duration, contended bool) {
+	if contended {
+		r.contended.Add(1)
+	}
+	r.waitTotal.Add(int64(d))
+	storeMax(&r.waitMax, int64(d))
+	i := sort.Search(len(Buckets), func(i int) bool { return d <= Buckets[i] })
+	r.histogram[i].Add(1)
+}
+
+// hold 记录一次持有时间，由 Unlock 在释放底层锁之前调用。持有时间创新高时记录 Unlock 调用方的栈
+func (r *record) hold(d time.Duration) {
+	r.holdTotal.Add(int64(d))
+	if storeMax(&r.holdMax, int64(d)) {
+		r.mu.Lock()
+		// 并发更新时以最终的最大值为准，较小的不覆盖
+		if int64(d) >= r.holdMax.Load() {
+			r.longest = callers(3, r.longest)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// storeMax 把 v 写入 max（如果更大），返回是否写入
+func storeMax(max *atomic.Int64, v int64) bool {
+	for {
+		cur := max.Load()
+		if v <= cur {
+			return false
+		}
+		if max.CompareAndSwap(cur, v) {
+			return true
+		}
+	}
+}
+
+func (r *record) snapshot() Stats {
+	s := Stats{
+		Name:             r.name,
+		Acquisitions:     r.acquisitions.Load(),
+		ReadAcquisitions: r.readAcquisitions.Load(),
+		Contended:        r.contended.Load(),
+		WaitTotal:        time.Duration(r.waitTotal.Load()),
+		WaitMax:          time.Duration(r.waitMax.Load()),
+		HoldTotal:        time.Duration(r.holdTotal.Load()),
+		HoldMax:          time.Duration(r.holdMax.Load()),
+		Waiters:          r.waiters.Load(),
+	}
+	for i := range r.histogram {
+		s.WaitHistogram[i] = r.histogram[i].Load()
+	}
+	r.mu.Lock()
+	s.LongestHolder = formatStack(r.longest)
+	r.mu.Unlock()
+	return s
+}
+
+func (r *record) reset() {
+	r.acquisitions.Store(0)
+	r.readAcquisitions.Store(0)
+	r.contended.Store(0)
+	r.waitTotal.Store(0)
+	r.waitMax.Store(0)
+	for i := range r.histogram {
+		r.histogram[i].Store(0)
+	}
+	r.holdTotal.Store(0)
+	r.holdMax.Store(0)
+	r.mu.Lock()
+	r.longest = nil
+	r.mu.Unlock()
+}
+
+// Snapshot 返回所有锁的统计，按总等待时间从多到少排序
+func Snapshot() []Stats {
+	registryMu.Lock()
+	records := make([]*record, 0, len(registry))
+	for _, r := range registry {
+		records = append(records, r)
+	}
+	registryMu.Unlock()
+
+	all := make([]Stats, 0, len(records))
+	for _, r := range records {
+		all = append(all, r.snapshot())
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].WaitTotal != all[j].WaitTotal {
+			return all[i].WaitTotal > all[j].WaitTotal
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Lookup 返回指定名字的统计
+func Lookup(name string) (Stats, bool) {
+	registryMu.Lock()
+	r, ok := registry[name]
+	registryMu.Unlock()
+	if !ok {
+		return Stats{}, false
+	}
+	return r.snapshot(), true
+}
+
+// Reset 清零所有统计（当前等待者数除外）
+func Reset() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, r := range registry {
+		r.reset()
+	}
+}
+
+// Report 输出所有锁的统计报告
+func Report(w io.Writer) {
+	for i, s := range Snapshot() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		s.Print(w)
+	}
+}
+
+// Print 输出一把锁的统计
+func (s Stats) Print(w io.Writer) {
+	fmt.Fprintf(w, "锁 %s\n", s.Name)
+	fmt.Fprintf(w, "  获取：写 %d 次，读 %d 次，其中 %d 次需要等待，当前等待者 %d\n",
+		s.Acquisitions, s.ReadAcquisitions, s.Contended, s.Waiters)
+	fmt.Fprintf(w, "  等待：总计 %v，平均 %v，最长 %v\n", s.WaitTotal, s.WaitMean(), s.WaitMax)
+	if s.Acquisitions > 0 {
+		fmt.Fprintf(w, "  持有：总计 %v，平均 %v，最长 %v\n", s.HoldTotal, s.HoldMean(), s.HoldMax)
+	}
+
+	var total uint64
+	for _, n := range s.WaitHistogram {
+		total += n
+	}
+	if total > 0 {
+		fmt.Fprintln(w, "  等待时间分布：")
+		for i, n := range s.WaitHistogram {
+			if n == 0 {
+				continue
+			}
+			label := "> " + Buckets[len(Buckets)-1].String()
+			if i < len(Buckets) {
+				label = "≤ " + Buckets[i].String()
+			}
+			fmt.Fprintf(w, "    %8s %-30s %d\n", label, strings.Repeat("█", max(int(n*30/total), 1)), n)
+		}
+	}
+	if s.LongestHolder != "" {
+		fmt.Fprintln(w, "  持有最久的那次解锁位置：")
+		for _, line := range strings.Split(strings.TrimRight(s.LongestHolder, "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// stackDepth 记录的调用栈深度
+const stackDepth = 16
+
+// callers 把调用栈记录到 buf（复用其底层数组），skip 跳过 lockstat 自身的栈帧
+func callers(skip int, buf []uintptr) []uintptr {
+	if cap(buf) < stackDepth {
+		buf = make([]uintptr, stackDepth)
+	}
+	buf = buf[:stackDepth]
+	return buf[:runtime.Callers(skip+1, buf)]
+}
+
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// callerName 未命名的锁以调用方的代码位置命名
+func callerName(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,196 @@
+package lockstat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func lookupT(t *testing.T, name string) Stats {
+	t.Helper()
+	s, ok := Lookup(name)
+	if !ok {
+		t.Fatalf("Lookup(%q) not found", name)
+	}
+	return s
+}
+
+func TestContention(t *testing.T) {
+	Reset() // 统计是全局的，-count 重复运行时从零开始
+	mu := Mutex{Name: "test.contention"}
+	mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mu.Lock() // 锁被持有，需要等待
+		mu.Unlock()
+	}()
+	waitFor(t, "a waiter", func() bool { return lookupT(t, mu.Name).Waiters == 1 })
+	time.Sleep(5 * time.Millisecond)
+	mu.Unlock()
+	<-done
+
+	if !mu.TryLock() {
+		t.Fatal("TryLock on a free mutex failed")
+	}
+	mu.Unlock()
+
+	s := lookupT(t, mu.Name)
+	if s.Acquisitions != 3 || s.Contended != 1 || s.Waiters != 0 {
+		t.Errorf("Acquisitions, Contended, Waiters = %d, %d, %d, want 3, 1, 0", s.Acquisitions, s.Contended, s.Waiters)
+	}
+	if s.WaitMax < 5*time.Millisecond {
+		t.Errorf("WaitMax = %v, want at least 5ms", s.WaitMax)
+	}
+	var total uint64
+	for _, n := range s.WaitHistogram {
+		total += n
+	}
+	if total != 3 {
+		t.Errorf("histogram total = %d, want 3", total)
+	}
+}
+
+func TestRWMutexContention(t *testing.T) {
+	Reset()
+	mu := RWMutex{Name: "test.rwcontention"}
+	mu.RLock()
+	mu.RLock() // 读锁之间不等待
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mu.Lock()
+		mu.Unlock()
+	}()
+	waitFor(t, "a writer", func() bool { return lookupT(t, mu.Name).Waiters == 1 })
+	mu.RUnlock()
+	mu.RUnlock()
+	<-done
+
+	s := lookupT(t, mu.Name)
+	if s.Acquisitions != 1 || s.ReadAcquisitions != 2 || s.Contended != 1 {
+		t.Errorf("Acquisitions, ReadAcquisitions, Contended = %d, %d, %d, want 1, 2, 1",
+			s.Acquisitions, s.ReadAcquisitions, s.Contended)
+	}
+}
+
+func TestRWMutexTryLock(t *testing.T) {
+	Reset()
+	mu := RWMutex{Name: "test.rwtrylock"}
+	if !mu.TryRLock() || !mu.TryRLock() {
+		t.Fatal("TryRLock on a read-locked mutex failed")
+	}
+	if mu.TryLock() {
+		t.Fatal("TryLock succeeded while read-locked")
+	}
+	mu.RUnlock()
+	mu.RUnlock()
+	if !mu.TryLock() {
+		t.Fatal("TryLock on a free mutex failed")
+	}
+	if mu.TryRLock() || mu.TryLock() {
+		t.Fatal("TryRLock or TryLock succeeded while write-locked")
+	}
+	mu.Unlock()
+
+	// 失败的尝试不计入统计
+	s := lookupT(t, mu.Name)
+	if s.Acquisitions != 1 || s.ReadAcquisitions != 2 || s.Contended != 0 {
+		t.Errorf("Acquisitions, ReadAcquisitions, Contended = %d, %d, %d, want 1, 2, 0",
+			s.Acquisitions, s.ReadAcquisitions, s.Contended)
+	}
+	var total uint64
+	for _, n := range s.WaitHistogram {
+		total += n
+	}
+	if total != 3 {
+		t.Errorf("histogram total = %d, want 3", total)
+	}
+}
+
+// holdFor 持有 mu 一段时间，用 defer 解锁
+func holdFor(mu *Mutex, d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	time.Sleep(d)
+}
+
+func TestLongestHolder(t *testing.T) {
+	Reset()
+	mu := Mutex{Name: "test.longest"}
+	mu.Lock()
+	mu.Unlock()
+	holdFor(&mu, 20*time.Millisecond)
+	mu.Lock() // 较短的持有不覆盖最长的那次
+	mu.Unlock()
+
+	s := lookupT(t, mu.Name)
+	if s.HoldMax < 20*time.Millisecond || s.HoldMax > s.HoldTotal {
+		t.Errorf("HoldMax = %v (total %v), want at least 20ms", s.HoldMax, s.HoldTotal)
+	}
+	if !strings.Contains(s.LongestHolder, "lockstat.holdFor") {
+		t.Errorf("LongestHolder =\n%s\nwant the stack of holdFor", s.LongestHolder)
+	}
+	if strings.Contains(s.LongestHolder, "lockstat.(*record).hold") {
+		t.Errorf("LongestHolder contains lockstat frames:\n%s", s.LongestHolder)
+	}
+
+	var buf bytes.Buffer
+	s.Print(&buf)
+	out := buf.String()
+	for _, want := range []string{"锁 test.longest", "获取：写 3 次", "持有最久的那次解锁位置：", "lockstat.holdFor"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print() =\n%s\nmissing %q", out, want)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	Reset()
+	defer SetEnabled(SetEnabled(false))
+	mu := Mutex{Name: "test.disabled"}
+	mu.Lock()
+	mu.Unlock()
+	if s, ok := Lookup(mu.Name); ok && s.Acquisitions != 0 {
+		t.Errorf("Acquisitions = %d while statistics were disabled", s.Acquisitions)
+	}
+
+	// 关闭期间获取的锁在开启后解锁，不计入持有时间
+	mu.Lock()
+	SetEnabled(true)
+	mu.Unlock()
+	mu.Lock()
+	mu.Unlock()
+	if s := lookupT(t, mu.Name); s.Acquisitions != 1 {
+		t.Errorf("Acquisitions = %d, want 1", s.Acquisitions)
+	}
+}
+
+func TestUnnamed(t *testing.T) {
+	Reset()
+	var mu Mutex
+	mu.Lock() // 以这一行命名
+	mu.Unlock()
+	found := false
+	for _, s := range Snapshot() {
+		if strings.HasPrefix(s.Name, "lockstat/lockstat_test.go:") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("unnamed lock not registered under its call site")
+	}
+}
@@ -0,0 +1,192 @@
+package lockstat
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mutex 带统计的互斥锁，可直接替换 sync.Mutex
+type Mutex struct {
+	Name string // 统计使用的名字，在第一次加锁前设置；为空时使用第一次加锁的代码位置
+
+	mu     sync.Mutex
+	rec    atomic.Pointer[record]
+	start  time.Time // 以下两个字段只由持有者读写
+	active bool      // 本次持有是否在统计开启时获取
+}
+
+// Lock 加锁，记录等待时间和加锁位置
+func (m *Mutex) Lock() {
+	if !enabled.Load() {
+		m.mu.Lock()
+		m.active = false
+		return
+	}
+	r := m.record()
+	begin := time.Now()
+	contended := !m.mu.TryLock()
+	if contended {
+		r.waiters.Add(1)
+		m.mu.Lock()
+		r.waiters.Add(-1)
+	}
+	m.acquired(r, begin, contended)
+}
+
+// TryLock 尝试加锁，成功时计入统计（等待时间为 0）
+func (m *Mutex) TryLock() bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	if !enabled.Load() {
+		m.active = false
+		return true
+	}
+	m.acquired(m.record(), time.Now(), false)
+	return true
+}
+
+// acquired 记录等待时间，持有时间从统计完成之后开始计算
+func (m *Mutex) acquired(r *record, begin time.Time, contended bool) {
+	r.acquisitions.Add(1)
+	r.wait(time.Since(begin), contended)
+	m.start, m.active = time.Now(), true
+}
+
+// Unlock 解锁，记录持有时间；持有时间创新高时记录调用栈
+func (m *Mutex) Unlock() {
+	if m.active {
+		m.active = false
+		m.rec.Load().hold(time.Since(m.start))
+	}
+	m.mu.Unlock()
+}
+
+// record 取得（第一次时登记）这把锁的统计
+func (m *Mutex) record() *record {
+	if r := m.rec.Load(); r != nil {
+		return r
+	}
+	name := m.Name
+	if name == "" {
+		name = callerName(2)
+	}
+	m.rec.CompareAndSwap(nil, lookup(name))
+	return m.rec.Load()
+}
+
+// RWMutex 带统计的读写锁，可直接替换 sync.RWMutex。读锁只统计等待时间
+type RWMutex struct {
+	Name string
+
+	mu     sync.RWMutex
+	rec    atomic.Pointer[record]
+	start  time.Time
+	active bool
+}
+
+// Lock 加写锁
+func (m *RWMutex) Lock() {
+	if !enabled.Load() {
+		m.mu.Lock()
+		m.active = false
+		return
+	}
+	r := m.record()
+	begin := time.Now()
+	contended := !m.mu.TryLock()
+	if contended {
+		r.waiters.Add(1)
+		m.mu.Lock()
+		r.waiters.Add(-1)
+	}
+	m.acquired(r, begin, contended)
+}
+
+// TryLock 尝试加写锁，成功时计入统计（等待时间为 0）
+func (m *RWMutex) TryLock() bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	if !enabled.Load() {
+		m.active = false
+		return true
+	}
+	m.acquired(m.record(), time.Now(), false)
+	return true
+}
+
+// acquired 与 Mutex.acquired 相同
+func (m *RWMutex) acquired(r *record, begin time.Time, contended bool) {
+	r.acquisitions.Add(1)
+	r.wait(time.Since(begin), contended)
+	m.start, m.active = time.Now(), true
+}
+
+// Unlock 解写锁
+func (m *RWMutex) Unlock() {
+	if m.active {
+		m.active = false
+		m.rec.Load().hold(time.Since(m.start))
+	}
+	m.mu.Unlock()
+}
+
+// RLock 加读锁
+func (m *RWMutex) RLock() {
+	if !enabled.Load() {
+		m.mu.RLock()
+		return
+	}
+	r := m.record()
+	begin := time.Now()
+	contended := !m.mu.TryRLock()
+	if contended {
+		r.waiters.Add(1)
+		m.mu.RLock()
+		r.waiters.Add(-1)
+	}
+	r.readAcquisitions.Add(1)
+	r.wait(time.Since(begin), contended)
+}
+
+// TryRLock 尝试加读锁，成功时计入统计（等待时间为 0）
+func (m *RWMutex) TryRLock() bool {
+	if !m.mu.TryRLock() {
+		return false
+	}
+	if enabled.Load() {
+		r := m.record()
+		r.readAcquisitions.Add(1)
+		r.wait(0, false)
+	}
+	return true
+}
+
+// RUnlock 解读锁
+func (m *RWMutex) RUnlock() {
+	m.mu.RUnlock()
+}
+
+// RLocker 返回以读锁实现的 sync.Locker
+func (m *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(m)
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock()   { (*RWMutex)(r).RLock() }
+func (r *rlocker) Unlock() { (*RWMutex)(r).RUnlock() }
+
+func (m *RWMutex) record() *record {
+	if r := m.rec.Load(); r != nil {
+		return r
+	}
+	name := m.Name
+	if name == "" {
+		name = callerName(2)
+	}
+	m.rec.CompareAndSwap(nil, lookup(name))
+	return m.rec.Load()
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	five_mu "github.com/ipodone/go-homework2/five-mu"
+	"github.com/ipodone/go-homework2/five-mu/racelab"
 	"github.com/ipodone/go-homework2/four_channel"
 	"github.com/ipodone/go-homework2/one_ptr"
 	"github.com/ipodone/go-homework2/three_object"
@@ -11,6 +17,36 @@ import (
 )
 
 func main() {
+	// go run . replay -file deadletter.jsonl [任务名...]：重放死信文件中的任务
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := replay(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "replay:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	// go run . bench [-trials 10 -procs 1,4,8 -goroutines 1,4,16,64]：输出锁策略对比的 Markdown 表格
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := bench(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "bench:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// go run . racelab [-trials 100]：运行故意写错的计数器，统计丢失的更新
+	if len(os.Args) > 1 && os.Args[1] == "racelab" {
+		if err := raceLab(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "racelab:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// go run . -deadletter deadletter.jsonl：保留 two_goroutine GetThree 写入的死信文件，用于 replay
+	deadletter := flag.String("deadletter", "", "two_goroutine GetThree 的死信文件，为空时写到临时目录并在演示结束后删除")
+	flag.Parse()
+
 	fmt.Println("one_ptr GetOne 开始===")
 	a := 1
 	fmt.Println("函数外部，修改前：", a)
@@ -37,6 +73,31 @@ func main() {
 	fmt.Println("two_goroutine GetTwo 结束===")
 	fmt.Println()
 
+	fmt.Println("two_goroutine GetThree 开始===")
+	two_goroutine.GetThree(*deadletter)
+	fmt.Println("two_goroutine GetThree 结束===")
+	fmt.Println()
+
+	fmt.Println("two_goroutine GetFour 开始===")
+	two_goroutine.GetFour()
+	fmt.Println("two_goroutine GetFour 结束===")
+	fmt.Println()
+
+	fmt.Println("two_goroutine GetFive 开始===")
+	two_goroutine.GetFive()
+	fmt.Println("two_goroutine GetFive 结束===")
+	fmt.Println()
+
+	fmt.Println("two_goroutine GetSix 开始===")
+	two_goroutine.GetSix()
+	fmt.Println("two_goroutine GetSix 结束===")
+	fmt.Println()
+
+	fmt.Println("two_goroutine GetSeven 开始===")
+	two_goroutine.GetSeven()
+	fmt.Println("two_goroutine GetSeven 结束===")
+	fmt.Println()
+
 	fmt.Println("three_goroutine GetOne 开始===")
 	three_object.GetOne()
 	fmt.Println("three_goroutine GetOne 结束===")
@@ -48,25 +109,156 @@ func main() {
 	fmt.Println()
 
 	fmt.Println("four_channel GetOne 开始===")
-	four_channel.GetOne()
+	four_channel.GetOne(os.Stdout)
 	fmt.Println("four_channel GetOne 结束===")
 	fmt.Println()
 
 	fmt.Println("four_channel GetTwo 开始===")
-	four_channel.GetTwo()
+	four_channel.GetTwo(os.Stdout)
 	fmt.Println("four_channel GetTwo 结束===")
 	fmt.Println()
 
+	fmt.Println("four_channel GetThree 开始===")
+	four_channel.GetThree()
+	fmt.Println("four_channel GetThree 结束===")
+	fmt.Println()
+
+	fmt.Println("four_channel GetFour 开始===")
+	four_channel.GetFour(os.Stdout)
+	fmt.Println("four_channel GetFour 结束===")
+	fmt.Println()
+
+	fmt.Println("four_channel GetFive 开始===")
+	four_channel.GetFive(os.Stdout)
+	fmt.Println("four_channel GetFive 结束===")
+	fmt.Println()
+
+	fmt.Println("four_channel GetSix 开始===")
+	four_channel.GetSix(os.Stdout)
+	fmt.Println("four_channel GetSix 结束===")
+	fmt.Println()
+
 	fmt.Println("five_mu GetOne 开始===")
-	five_mu.GetOne()
-	five_mu.GetTwo()
+	five_mu.GetOne(10, 1000)
+	five_mu.GetTwo(10, 1000)
 	fmt.Println("five_mu GetOne 结束===")
 	fmt.Println()
 
 	fmt.Println("five_mu GetTwo 开始===")
-	five_mu.GetThree()
-	five_mu.GetFour()
-	five_mu.GetFive()
+	five_mu.GetThree(10, 1000)
+	five_mu.GetFour(10, 1000)
+	five_mu.GetFive(10, 1000)
 	fmt.Println("five_mu GetTwo 结束===")
 	fmt.Println()
+
+	fmt.Println("five_mu GetSix 开始===")
+	five_mu.GetSix(10, 1000)
+	fmt.Println("five_mu GetSix 结束===")
+	fmt.Println()
+}
+
+// replay 列出死信文件中的任务，并把选中的任务（默认全部）重新提交给调度器执行。
+// 任务函数按名字从 two_goroutine.ReplayTasks() 中查找，重放成功的死信会从文件中删除。
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	file := fs.String("file", "deadletter.jsonl", "JSON-lines 死信文件")
+	list := fs.Bool("list", false, "只列出死信，不重放")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store := two_goroutine.NewFileDeadLetterStore(*file)
+	if *list {
+		letters, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, dl := range letters {
+			fmt.Printf("%s\t尝试 %d 次\t%s\t%v\n", dl.Name, dl.Attempts, dl.FailedAt.Format("2006-01-02 15:04:05"), dl.Errors)
+		}
+		return nil
+	}
+
+	scheduler := two_goroutine.NewTaskScheduler()
+	names, err := two_goroutine.Replay(store, scheduler, two_goroutine.ReplayTasks(), fs.Args()...)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("没有需要重放的死信")
+		return nil
+	}
+	fmt.Println("重放死信：", names)
+	scheduler.Execute()
+	scheduler.PrintSummary()
+	return nil
+}
+
+// bench 用统计基准工具比较 five_mu 的互斥锁、读写锁、原子操作、分片和通道计数器
+func bench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	warmup := fs.Int("warmup", 2, "每个组合的预热次数，0 表示不预热")
+	trials := fs.Int("trials", 10, "每个组合的测量次数")
+	iterations := fs.Int("iterations", 10000, "每个协程的递增次数")
+	goroutines := fs.String("goroutines", "1,4,16,64", "逗号分隔的协程数")
+	procs := fs.String("procs", "", "逗号分隔的 GOMAXPROCS 取值，默认为当前值")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := five_mu.BenchConfig{Warmup: *warmup, NoWarmup: *warmup == 0, Trials: *trials, Iterations: *iterations}
+	var err error
+	if cfg.Goroutines, err = parseInts(*goroutines); err != nil {
+		return err
+	}
+	if cfg.Procs, err = parseInts(*procs); err != nil {
+		return err
+	}
+	results, err := five_mu.Bench(cfg, five_mu.BenchCounters()...)
+	if err != nil {
+		return err
+	}
+	fmt.Print(five_mu.Markdown(results))
+	return nil
+}
+
+// raceLab 依次运行 racelab 的每个变体并输出报告
+func raceLab(args []string) error {
+	fs := flag.NewFlagSet("racelab", flag.ContinueOnError)
+	trials := fs.Int("trials", 100, "每个变体的运行次数")
+	goroutines := fs.Int("goroutines", 10, "协程数")
+	iterations := fs.Int("iterations", 1000, "每个协程的递增次数")
+	timeout := fs.Duration("timeout", 100*time.Millisecond, "单次运行的超时时间（防止死锁的变体卡住）")
+	deadlockTrials := fs.Int("deadlock-trials", 3, "会死锁的变体的运行次数，每次都会泄漏被阻塞的协程")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	for i, v := range racelab.Variants() {
+		n := *trials
+		if v.Deadlocks {
+			n = *deadlockTrials
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		racelab.Run(v, *goroutines, *iterations, n, *timeout).Print(os.Stdout)
+	}
+	return nil
+}
+
+// parseInts 解析逗号分隔的正整数列表，空字符串返回 nil
+func parseInts(s string) ([]int, error) {
+	var nums []int
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("无效的数值 %q", field)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
 }
@@ -0,0 +1,90 @@
+package netchan
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 帧格式（大端序）：
+//
+//	+-------+-------+---------+------+--------+---------+
+//	| 'N'   | 'C'   | version | type | length | payload |
+//	| 1 字节 | 1 字节 | 1 字节   | 1 字节 | 4 字节  | length  |
+//	+-------+-------+---------+------+--------+---------+
+//
+// 每一帧都带版本号，接收到不支持的版本时立即报错，而不是把数据解码成垃圾。
+
+// Version 当前协议版本
+const Version = 1
+
+// MaxFrameSize 单帧负载的上限，防止对端发送异常长度耗尽内存
+const MaxFrameSize = 16 << 20
+
+const headerSize = 8
+
+// 帧类型
+const (
+	frameHello  byte = iota + 1 // 接收端 → 发送端：握手，负载为接收端的缓冲容量
+	frameData                   // 发送端 → 接收端：一个元素，负载为 JSON
+	frameCredit                 // 接收端 → 发送端：归还额度，负载为额度数
+	frameClose                  // 发送端 → 接收端：发送端已关闭，没有更多元素
+)
+
+var (
+	// ErrVersion 对端的协议版本不受支持
+	ErrVersion = errors.New("netchan: 协议版本不匹配")
+	// ErrClosed 通道已关闭
+	ErrClosed = errors.New("netchan: 已关闭")
+)
+
+// writeFrame 写入一帧，调用方负责串行化对同一连接的写入
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	if len(payload) > MaxFrameSize {
+		return fmt.Errorf("netchan: 帧大小 %d 超过上限 %d", len(payload), MaxFrameSize)
+	}
+	buf := make([]byte, headerSize+len(payload))
+	buf[0], buf[1], buf[2], buf[3] = 'N', 'C', Version, typ
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(payload)))
+	copy(buf[headerSize:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame 读取一帧
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	if header[0] != 'N' || header[1] != 'C' {
+		return 0, nil, errors.New("netchan: 无效的帧头")
+	}
+	if header[2] != Version {
+		return 0, nil, fmt.Errorf("%w：对端 %d，本端 %d", ErrVersion, header[2], Version)
+	}
+	n := binary.BigEndian.Uint32(header[4:])
+	if n > MaxFrameSize {
+		return 0, nil, fmt.Errorf("netchan: 帧大小 %d 超过上限 %d", n, MaxFrameSize)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	return header[3], payload, nil
+}
+
+func encodeUint32(n uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, n)
+}
+
+func decodeUint32(payload []byte) (uint32, error) {
+	if len(payload) != 4 {
+		return 0, fmt.Errorf("netchan: 无效的负载长度 %d", len(payload))
+	}
+	return binary.BigEndian.Uint32(payload), nil
+}
@@ -0,0 +1,345 @@
+package netchan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// 跨进程通道：把 four_channel 的生产者/消费者模式搬到两个进程之间。
+// 发送端（Sender）在一个进程里 Send，接收端（Receiver）在另一个进程里 range r.C，
+// 两端通过 TCP 或 Unix socket 连接，元素以 JSON 编码放在带版本号的帧里（见 frame.go）。
+//
+// 流量控制与本地通道的缓冲容量一致：接收端握手时告知容量 N，发送端最多有 N 个未被取走的元素，
+// 接收方的应用每取走一个元素就归还一个额度，额度用完时 Send 阻塞，就像向已满的缓冲通道发送。
+// 容量为 0 时模拟无缓冲通道：Send 要等对端真正取走该元素才返回。
+//
+// 关闭传播：发送端 Close 后，接收端取完剩余元素就关闭 C，远端的 range 循环与本地一样自然结束。
+// 连接异常断开时 C 同样会关闭，此时 Err 返回断开的原因。
+
+// closeTimeout 发送端关闭时等待接收端确认的最长时间
+const closeTimeout = 5 * time.Second
+
+// Sender 通道的发送端
+type Sender[T any] struct {
+	conn     net.Conn
+	capacity int
+
+	wmu sync.Mutex // 串行化写入
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	credits  int    // 当前可用额度
+	sent     uint64 // 已发送的元素数
+	returned uint64 // 接收端已取走（归还额度）的元素数
+	closed   bool
+	err      error
+	done     chan struct{} // 读协程退出后关闭
+}
+
+// Dial 连接到接收端并创建发送端，network 为 "tcp" 或 "unix"
+func Dial[T any](network, addr string) (*Sender[T], error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSender[T](conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSender 在已建立的连接上创建发送端，会等待接收端的握手
+func NewSender[T any](conn net.Conn) (*Sender[T], error) {
+	typ, payload, err := readFrame(conn)
+	if err != nil {
+		return nil, err
+	}
+	if typ != frameHello {
+		return nil, fmt.Errorf("netchan: 期望握手帧，收到类型 %d", typ)
+	}
+	capacity, err := decodeUint32(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sender[T]{
+		conn:     conn,
+		capacity: int(capacity),
+		credits:  max(int(capacity), 1),
+		done:     make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.readLoop()
+	return s, nil
+}
+
+// Cap 返回接收端的缓冲容量
+func (s *Sender[T]) Cap() int {
+	return s.capacity
+}
+
+// Send 发送一个元素。额度用完时阻塞；无缓冲时等接收方取走后才返回
+func (s *Sender[T]) Send(v T) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for s.credits == 0 && !s.closed && s.err == nil {
+		s.cond.Wait()
+	}
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return err
+	}
+	s.credits--
+	s.mu.Unlock()
+
+	// 持有 wmu 再检查一次是否已开始关闭：Close 先标记 closed 再在 wmu 下写关闭帧，
+	// 因此数据帧要么在关闭帧之前写出，要么 Send 返回 ErrClosed，不会出现在关闭帧之后
+	s.wmu.Lock()
+	s.mu.Lock()
+	if s.closed {
+		s.credits++
+		s.mu.Unlock()
+		s.wmu.Unlock()
+		return ErrClosed
+	}
+	s.sent++
+	seq := s.sent
+	s.mu.Unlock()
+	err = writeFrame(s.conn, frameData, payload)
+	s.wmu.Unlock()
+	if err != nil {
+		s.fail(err)
+		return err
+	}
+
+	if s.capacity == 0 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for s.returned < seq && s.err == nil {
+			s.cond.Wait()
+		}
+		return s.err
+	}
+	return nil
+}
+
+// Close 通知接收端不会再有元素，并等待接收端确认后关闭连接。
+// 已经写出数据帧的 Send 正常返回，其余并发或之后的 Send 返回 ErrClosed。
+func (s *Sender[T]) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.wmu.Lock()
+	err := writeFrame(s.conn, frameClose, nil)
+	s.wmu.Unlock()
+
+	// 接收端收到关闭帧后会关闭连接，读协程随之退出；
+	// 在此之前直接关闭连接可能让未读完的数据被对端丢弃
+	if err == nil {
+		s.conn.SetReadDeadline(time.Now().Add(closeTimeout))
+		<-s.done
+	}
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readLoop 读取接收端归还的额度
+func (s *Sender[T]) readLoop() {
+	defer close(s.done)
+	for {
+		typ, payload, err := readFrame(s.conn)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = fmt.Errorf("%w：接收端已断开", ErrClosed)
+			}
+			s.fail(err)
+			return
+		}
+		if typ != frameCredit {
+			s.fail(fmt.Errorf("netchan: 发送端收到意外的帧类型 %d", typ))
+			return
+		}
+		n, err := decodeUint32(payload)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		s.mu.Lock()
+		s.credits += int(n)
+		s.returned += uint64(n)
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// fail 记录第一个错误并唤醒所有等待者
+func (s *Sender[T]) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.cond.Broadcast()
+}
+
+// Receiver 通道的接收端，通过 C 接收元素
+type Receiver[T any] struct {
+	C <-chan T
+
+	conn net.Conn
+	in   chan T // 读协程放入，缓冲等于额度，遵守流控的发送端不会让它阻塞
+	out  chan T
+	stop chan struct{}
+	once sync.Once
+
+	wmu          sync.Mutex // 串行化写入，保护 remoteClosed
+	remoteClosed bool       // 已收到关闭帧，不再归还额度
+
+	mu  sync.Mutex
+	err error
+}
+
+// Accept 从监听器接受一个连接并创建接收端，buffer 为通道的缓冲容量
+func Accept[T any](ln net.Listener, buffer int) (*Receiver[T], error) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReceiver[T](conn, buffer)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewReceiver 在已建立的连接上创建接收端，buffer 为通道的缓冲容量
+func NewReceiver[T any](conn net.Conn, buffer int) (*Receiver[T], error) {
+	if buffer < 0 {
+		return nil, fmt.Errorf("netchan: 无效的缓冲容量 %d", buffer)
+	}
+	if err := writeFrame(conn, frameHello, encodeUint32(uint32(buffer))); err != nil {
+		return nil, err
+	}
+
+	out := make(chan T)
+	r := &Receiver[T]{
+		C:    out,
+		conn: conn,
+		in:   make(chan T, max(buffer, 1)),
+		out:  out,
+		stop: make(chan struct{}),
+	}
+	go r.readLoop()
+	go r.forward()
+	return r, nil
+}
+
+// Err 返回导致 C 提前关闭的错误，发送端正常关闭时为 nil
+func (r *Receiver[T]) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close 放弃接收剩余元素并断开连接，之后发送端的 Send 会返回错误
+func (r *Receiver[T]) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	return r.conn.Close()
+}
+
+// readLoop 读取数据帧放入 in，收到关闭帧或出错时关闭 in
+func (r *Receiver[T]) readLoop() {
+	defer close(r.in)
+	for {
+		typ, payload, err := readFrame(r.conn)
+		if err != nil {
+			select {
+			case <-r.stop: // 本端主动关闭
+			default:
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF // 没有收到关闭帧就断开了
+				}
+				r.setErr(err)
+			}
+			return
+		}
+
+		switch typ {
+		case frameData:
+			var v T
+			if err := json.Unmarshal(payload, &v); err != nil {
+				r.setErr(err)
+				r.conn.Close()
+				return
+			}
+			select {
+			case r.in <- v:
+			case <-r.stop:
+				return
+			}
+		case frameClose:
+			r.wmu.Lock()
+			r.remoteClosed = true
+			r.conn.Close() // 发送端在等待这次关闭
+			r.wmu.Unlock()
+			return
+		default:
+			r.setErr(fmt.Errorf("netchan: 接收端收到意外的帧类型 %d", typ))
+			r.conn.Close()
+			return
+		}
+	}
+}
+
+// forward 把元素交给应用，每交出一个就归还一个额度
+func (r *Receiver[T]) forward() {
+	defer close(r.out)
+	for v := range r.in {
+		select {
+		case r.out <- v:
+		case <-r.stop:
+			return
+		}
+
+		r.wmu.Lock()
+		if !r.remoteClosed {
+			// 写失败说明连接已断开，读协程会记录错误
+			writeFrame(r.conn, frameCredit, encodeUint32(1))
+		}
+		r.wmu.Unlock()
+	}
+}
+
+func (r *Receiver[T]) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
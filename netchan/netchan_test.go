@@ -0,0 +1,216 @@
+package netchan
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+// pipe 在 net.Pipe 上建立一对发送端和接收端
+func pipe[T any](t *testing.T, buffer int) (*Sender[T], *Receiver[T]) {
+	t.Helper()
+	a, b := net.Pipe()
+	type result struct {
+		r   *Receiver[T]
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		r, err := NewReceiver[T](b, buffer) // net.Pipe 没有缓冲，握手帧要等发送端读取
+		ch <- result{r, err}
+	}()
+	s, err := NewSender[T](a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-ch
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return s, res.r
+}
+
+// returnsWithin 报告 fn 是否在 d 内返回，没有返回时 fn 会在后台继续执行
+func returnsWithin(d time.Duration, fn func()) (<-chan struct{}, bool) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+		return done, true
+	case <-time.After(d):
+		return done, false
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	s, r := pipe[item](t, 3)
+	if s.Cap() != 3 {
+		t.Errorf("Cap() = %d, want 3", s.Cap())
+	}
+	want := item{ID: 1, Name: "订单"}
+	if err := s.Send(want); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-r.C; got != want {
+		t.Errorf("received %+v, want %+v", got, want)
+	}
+}
+
+func TestVersionMismatch(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	go func() {
+		// 版本号为 Version+1 的握手帧
+		b.Write([]byte{'N', 'C', Version + 1, frameHello, 0, 0, 0, 4, 0, 0, 0, 1})
+	}()
+	if _, err := NewSender[int](a); !errors.Is(err, ErrVersion) {
+		t.Errorf("NewSender() = %v, want ErrVersion", err)
+	}
+}
+
+func TestCredits(t *testing.T) {
+	s, r := pipe[int](t, 2)
+
+	// 额度为 2：前两个不阻塞
+	for i := 1; i <= 2; i++ {
+		if _, ok := returnsWithin(time.Second, func() { s.Send(i) }); !ok {
+			t.Fatalf("Send(%d) blocked with credits available", i)
+		}
+	}
+	// 额度用完，第三个阻塞
+	done, ok := returnsWithin(50*time.Millisecond, func() { s.Send(3) })
+	if ok {
+		t.Fatal("Send(3) did not block after credits ran out")
+	}
+	// 取走一个，归还一个额度
+	if v := <-r.C; v != 1 {
+		t.Errorf("received %d, want 1", v)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Send(3) still blocked after a credit was returned")
+	}
+	for want := 2; want <= 3; want++ {
+		if v := <-r.C; v != want {
+			t.Errorf("received %d, want %d", v, want)
+		}
+	}
+}
+
+func TestRendezvous(t *testing.T) {
+	s, r := pipe[int](t, 0)
+	for i := 1; i <= 3; i++ {
+		done, ok := returnsWithin(50*time.Millisecond, func() {
+			if err := s.Send(i); err != nil {
+				t.Error(err)
+			}
+		})
+		if ok {
+			t.Fatalf("Send(%d) returned before the receiver took it", i)
+		}
+		if v := <-r.C; v != i {
+			t.Errorf("received %d, want %d", v, i)
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Send(%d) still blocked after the receiver took it", i)
+		}
+	}
+}
+
+func TestClosePropagation(t *testing.T) {
+	s, r := pipe[int](t, 4)
+	for i := 1; i <= 3; i++ {
+		if err := s.Send(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if err := s.Send(4); !errors.Is(err, ErrClosed) {
+		t.Errorf("Send after Close = %v, want ErrClosed", err)
+	}
+
+	var got []int
+	for v := range r.C { // 取完剩余元素后 C 关闭
+		got = append(got, v)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("received %v, want %v", got, want)
+	}
+	if err := r.Err(); err != nil {
+		t.Errorf("Err() after clean close = %v", err)
+	}
+}
+
+func TestReceiverClose(t *testing.T) {
+	s, r := pipe[int](t, 1)
+	r.Close()
+	if _, ok := <-r.C; ok {
+		t.Error("C not closed after Receiver.Close")
+	}
+	var err error
+	for i := 0; err == nil && i < 10; i++ {
+		err = s.Send(i)
+	}
+	if err == nil {
+		t.Error("Send kept succeeding after the receiver closed")
+	}
+}
+
+func TestSendRacingClose(t *testing.T) {
+	for range 20 {
+		s, r := pipe[int](t, 1000)
+
+		var ok atomic.Int64
+		var wg sync.WaitGroup
+		for g := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 50 {
+					if err := s.Send(g*100 + i); err == nil {
+						ok.Add(1)
+					} else if !errors.Is(err, ErrClosed) {
+						t.Errorf("Send() = %v", err)
+						return
+					}
+				}
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		if err := s.Close(); err != nil {
+			t.Fatalf("Close() = %v", err)
+		}
+		wg.Wait()
+
+		// 返回 nil 的 Send 都必须送达
+		received := 0
+		for range r.C {
+			received++
+		}
+		if int64(received) != ok.Load() {
+			t.Fatalf("received %d items, but %d Sends returned nil", received, ok.Load())
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 泛型流水线：把 four_channel 里 sendOnly/receiveOnly 的单生产者-单消费者模式
+// 推广为任意类型、任意多阶段的流水线。
+// 每个阶段是一个（或一组）协程，阶段之间用通道连接：上游只发送（chan<-），下游只接收（<-chan）。
+// 所有阶段共享同一个 context，任何阶段返回错误都会取消 context，
+// 各阶段的发送和接收都同时监听 ctx.Done()，因此会全部退出，不会泄漏协程。
+
+// Pipeline 管理一组阶段协程的生命周期
+type Pipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// New 创建流水线，ctx 取消时流水线也会停止
+func New(ctx context.Context) *Pipeline {
+	inner, cancel := context.WithCancel(ctx)
+	return &Pipeline{parent: ctx, ctx: inner, cancel: cancel}
+}
+
+// Context 返回流水线的 context，流水线出错或停止时会被取消
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Go 启动一个阶段协程，fn 返回错误时拆除整条流水线
+func (p *Pipeline) Go(fn func(ctx context.Context) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := fn(p.ctx); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// Stop 主动停止流水线
+func (p *Pipeline) Stop() {
+	p.cancel()
+}
+
+// Wait 等待所有阶段协程退出，返回第一个阶段错误；
+// 由外部 ctx 取消导致的停止返回 ctx.Err()，调用 Stop 主动停止返回 nil
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	if p.err == nil {
+		return p.parent.Err()
+	}
+	return p.err
+}
+
+// fail 记录第一个错误并取消 context。
+// 流水线已取消后阶段返回的 context 错误只是连带结果，不作为根因记录
+func (p *Pipeline) fail(err error) {
+	if p.ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return
+	}
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// send 向通道发送 v，流水线取消时放弃发送并返回 false
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// checkNoLeak 等待协程数回落到 base，超时则报告泄漏
+func checkNoLeak(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("goroutines = %d, want <= %d\n%s", runtime.NumGoroutine(), base, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func square(_ context.Context, v int) (int, error) { return v * v, nil }
+
+func TestParallelMapOrder(t *testing.T) {
+	for _, workers := range []int{-1, 0, 1, 4} {
+		p := New(context.Background())
+		src := Source(p, 1, 2, 3, 4, 5, 6, 7, 8)
+		got, err := Collect(p, ParallelMap(p, src, workers, func(_ context.Context, v int) (int, error) {
+			time.Sleep(time.Duration(8-v) * time.Millisecond) // 后面的元素先完成
+			return v * v, nil
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{1, 4, 9, 16, 25, 36, 49, 64}; !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d: got %v, want %v", workers, got, want)
+		}
+	}
+}
+
+func TestFanOutMerge(t *testing.T) {
+	for _, workers := range []int{0, 3} {
+		p := New(context.Background())
+		outs := FanOut(p, Source(p, 1, 2, 3, 4), workers, square)
+		if want := max(workers, 1); len(outs) != want {
+			t.Errorf("FanOut(%d) returned %d channels, want %d", workers, len(outs), want)
+		}
+		got, err := Collect(p, Merge(p, outs...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(got)
+		if want := []int{1, 4, 9, 16}; !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d: got %v, want %v", workers, got, want)
+		}
+	}
+}
+
+// infinite 持续生产递增的整数，直到流水线取消
+func infinite(p *Pipeline) <-chan int {
+	return Generate(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 0; ; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+	})
+}
+
+func TestStopMidStream(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	p := New(context.Background())
+	squares := ParallelMap(p, infinite(p), 4, square)
+	merged := Merge(p, FanOut(p, squares, 3, square)...)
+	for range 10 {
+		<-merged
+	}
+	p.Stop()
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() after Stop = %v, want nil", err)
+	}
+	checkNoLeak(t, base)
+}
+
+func TestParentCancel(t *testing.T) {
+	base := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	out := Map(p, infinite(p), square)
+	<-out
+	cancel()
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() = %v, want context.Canceled", err)
+	}
+	checkNoLeak(t, base)
+}
+
+func TestStageError(t *testing.T) {
+	errBoom := errors.New("boom")
+	stages := map[string]func(p *Pipeline, in <-chan int, fn func(context.Context, int) (int, error)) <-chan int{
+		"Map": func(p *Pipeline, in <-chan int, fn func(context.Context, int) (int, error)) <-chan int {
+			return Map(p, in, fn)
+		},
+		"FanOut": func(p *Pipeline, in <-chan int, fn func(context.Context, int) (int, error)) <-chan int {
+			return Merge(p, FanOut(p, in, 3, fn)...)
+		},
+		"ParallelMap": func(p *Pipeline, in <-chan int, fn func(context.Context, int) (int, error)) <-chan int {
+			return ParallelMap(p, in, 3, fn)
+		},
+	}
+	for name, stage := range stages {
+		t.Run(name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+
+			p := New(context.Background())
+			out := stage(p, infinite(p), func(_ context.Context, v int) (int, error) {
+				if v == 100 {
+					return 0, errBoom
+				}
+				return v, nil
+			})
+			_, err := Collect(p, out)
+			if !errors.Is(err, errBoom) {
+				t.Errorf("Collect() error = %v, want %v", err, errBoom)
+			}
+			checkNoLeak(t, base)
+		})
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	base := runtime.NumGoroutine()
+	errBoom := errors.New("boom")
+
+	p := New(context.Background())
+	Sink(p, ParallelMap(p, infinite(p), 2, square), func(_ context.Context, v int) error {
+		if v > 50 {
+			return errBoom
+		}
+		return nil
+	})
+	if err := p.Wait(); !errors.Is(err, errBoom) {
+		t.Errorf("Wait() = %v, want %v", err, errBoom)
+	}
+	checkNoLeak(t, base)
+}
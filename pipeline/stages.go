@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Source 把给定的元素依次发送到输出通道
+func Source[T any](p *Pipeline, items ...T) <-chan T {
+	return Generate(p, func(ctx context.Context, emit func(T) bool) error {
+		for _, v := range items {
+			if !emit(v) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Generate 用 fn 生产元素：fn 调用 emit 发送元素，emit 返回 false 表示流水线已取消，fn 应立即返回。
+// fn 返回后输出通道关闭，下游的 range 循环随之结束。
+func Generate[T any](p *Pipeline, fn func(ctx context.Context, emit func(T) bool) error) <-chan T {
+	out := make(chan T)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		return fn(ctx, func(v T) bool { return send(ctx, out, v) })
+	})
+	return out
+}
+
+// Map 单协程阶段：对每个元素调用 fn，把结果发往下游
+func Map[In, Out any](p *Pipeline, in <-chan In, fn func(ctx context.Context, v In) (Out, error)) <-chan Out {
+	out := make(chan Out)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		return mapLoop(ctx, in, out, fn)
+	})
+	return out
+}
+
+// FanOut 启动 workers 个协程从同一个输入通道竞争读取，每个协程有自己的输出通道。
+// 元素之间不保证顺序，需要合并时使用 Merge，需要保持顺序时使用 ParallelMap。workers < 1 时按 1 处理。
+func FanOut[In, Out any](p *Pipeline, in <-chan In, workers int, fn func(ctx context.Context, v In) (Out, error)) []<-chan Out {
+	workers = max(workers, 1)
+	outs := make([]<-chan Out, workers)
+	for i := range outs {
+		out := make(chan Out)
+		outs[i] = out
+		p.Go(func(ctx context.Context) error {
+			defer close(out)
+			return mapLoop(ctx, in, out, fn)
+		})
+	}
+	return outs
+}
+
+// Merge 把多个输入通道合并为一个，所有输入关闭后输出才关闭
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			for {
+				select {
+				case v, ok := <-in:
+					if !ok || !send(ctx, out, v) {
+						return nil
+					}
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		})
+	}
+	p.Go(func(ctx context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// ParallelMap 用 workers 个协程并行处理，输出顺序与输入顺序一致。
+// 分发协程为每个元素分配一个结果通道并按输入顺序排队，收集协程按队列顺序等待结果，
+// 因此最多有 workers 个元素在处理或等待输出。workers < 1 时按 1 处理，否则没有协程消费任务，流水线会挂住。
+func ParallelMap[In, Out any](p *Pipeline, in <-chan In, workers int, fn func(ctx context.Context, v In) (Out, error)) <-chan Out {
+	workers = max(workers, 1)
+	type job struct {
+		v      In
+		result chan Out
+	}
+	jobs := make(chan job)
+	pending := make(chan chan Out, workers)
+	out := make(chan Out)
+
+	// 分发：按输入顺序登记结果通道，再交给工作协程
+	p.Go(func(ctx context.Context) error {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return nil
+				}
+				j := job{v: v, result: make(chan Out, 1)}
+				if !send(ctx, pending, j.result) || !send(ctx, jobs, j) {
+					return nil
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+
+	// 工作协程：结果通道有 1 个缓冲，写入不会阻塞
+	for range workers {
+		p.Go(func(ctx context.Context) error {
+			for j := range jobs {
+				r, err := fn(ctx, j.v)
+				if err != nil {
+					return err
+				}
+				j.result <- r
+			}
+			return nil
+		})
+	}
+
+	// 收集：按登记顺序输出
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for result := range pending {
+			select {
+			case r := <-result:
+				if !send(ctx, out, r) {
+					return nil
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Sink 消费输入通道中的每个元素，fn 返回错误时拆除流水线
+func Sink[T any](p *Pipeline, in <-chan T, fn func(ctx context.Context, v T) error) {
+	p.Go(func(ctx context.Context) error {
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					return nil
+				}
+				if err := fn(ctx, v); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+}
+
+// Collect 把输入通道中的元素收集为切片，并等待整条流水线结束
+func Collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var items []T
+	Sink(p, in, func(_ context.Context, v T) error {
+		items = append(items, v)
+		return nil
+	})
+	err := p.Wait()
+	return items, err
+}
+
+// mapLoop 从 in 读取、调用 fn、写入 out，直到 in 关闭、fn 出错或流水线取消
+func mapLoop[In, Out any](ctx context.Context, in <-chan In, out chan<- Out, fn func(ctx context.Context, v In) (Out, error)) error {
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return nil
+			}
+			r, err := fn(ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(ctx, out, r) {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package prioritychan
+
+import (
+	"fmt"
+	"iter"
+	"sync"
+)
+
+// 多车道优先级通道：select 在多个就绪的通道之间随机挑选，紧急消息可能排在大量普通消息后面。
+// Chan 把 N 条车道放在同一个结构里，车道 0 优先级最高，接收方总是先取完高优先级车道。
+//
+// 饥饿保护（可选）：高优先级车道持续有消息时，低优先级车道可能永远轮不到。
+// 设置 WithStarvationGuard(n) 后，有消息的车道每被更高车道"插队"一次就计数一次，
+// 计数达到 n 时下一次接收优先服务它（多个车道同时达到时先服务优先级高的），随后计数清零。
+//
+// 关闭语义与内置通道一致：
+//   - 向已关闭的 Chan 发送会 panic，重复关闭也会 panic
+//   - 关闭后接收方仍能取完所有车道中剩余的元素，之后 Recv 返回零值和 false
+//   - All 返回的迭代器在关闭且取空后结束，可以像 range ch 一样使用
+
+// Chan 多车道优先级通道
+type Chan[T any] struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond // 有新元素或关闭时广播
+	notFull  *sync.Cond // 有元素被取走或关闭时广播
+	lanes    [][]T      // 每条车道的队列，队头最旧
+	capacity int
+	skipped  []int // 每条车道有元素却被更高车道插队的次数
+	guard    int   // 饥饿保护阈值，0 表示关闭
+	closed   bool
+}
+
+// New 创建 lanes 条车道、每条车道缓冲 capacity 个元素的优先级通道。
+// 车道需要排队，因此容量至少为 1。
+func New[T any](lanes, capacity int) *Chan[T] {
+	if lanes <= 0 {
+		panic(fmt.Sprintf("prioritychan: 无效的车道数 %d", lanes))
+	}
+	c := &Chan[T]{
+		lanes:    make([][]T, lanes),
+		capacity: max(capacity, 1),
+		skipped:  make([]int, lanes),
+	}
+	c.notEmpty = sync.NewCond(&c.mu)
+	c.notFull = sync.NewCond(&c.mu)
+	return c
+}
+
+// WithStarvationGuard 设置饥饿保护阈值，n <= 0 表示关闭（严格按优先级）。应在开始收发前调用
+func (c *Chan[T]) WithStarvationGuard(n int) *Chan[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.guard = max(n, 0)
+	return c
+}
+
+// Lanes 返回车道数
+func (c *Chan[T]) Lanes() int {
+	return len(c.lanes)
+}
+
+// Len 返回所有车道中尚未取走的元素数
+func (c *Chan[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, lane := range c.lanes {
+		n += len(lane)
+	}
+	return n
+}
+
+// Send 向车道 lane 发送 v，该车道已满时阻塞。向已关闭的 Chan 发送会 panic
+func (c *Chan[T]) Send(lane int, v T) {
+	c.checkLane(lane)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for !c.closed && len(c.lanes[lane]) >= c.capacity {
+		c.notFull.Wait()
+	}
+	c.push(lane, v)
+}
+
+// TrySend 非阻塞发送，车道已满时返回 false。向已关闭的 Chan 发送会 panic
+func (c *Chan[T]) TrySend(lane int, v T) bool {
+	c.checkLane(lane)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed && len(c.lanes[lane]) >= c.capacity {
+		return false
+	}
+	c.push(lane, v)
+	return true
+}
+
+// Recv 取出优先级最高的元素，所有车道为空时阻塞；关闭且取空后返回零值和 false
+func (c *Chan[T]) Recv() (T, bool) {
+	v, _, ok := c.RecvLane()
+	return v, ok
+}
+
+// RecvLane 与 Recv 相同，同时返回元素所在的车道
+func (c *Chan[T]) RecvLane() (T, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if lane := c.pick(); lane >= 0 {
+			return c.pop(lane), lane, true
+		}
+		if c.closed {
+			var zero T
+			return zero, -1, false
+		}
+		c.notEmpty.Wait()
+	}
+}
+
+// TryRecv 非阻塞接收，所有车道为空时返回 false
+func (c *Chan[T]) TryRecv() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lane := c.pick(); lane >= 0 {
+		return c.pop(lane), true
+	}
+	var zero T
+	return zero, false
+}
+
+// All 按优先级依次产出（车道, 元素），直到 Chan 关闭且取空，用法同 range ch
+func (c *Chan[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for {
+			v, lane, ok := c.RecvLane()
+			if !ok || !yield(lane, v) {
+				return
+			}
+		}
+	}
+}
+
+// Close 关闭通道，唤醒所有等待者。重复关闭会 panic
+func (c *Chan[T]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		panic("prioritychan: close of closed channel")
+	}
+	c.closed = true
+	c.notEmpty.Broadcast()
+	c.notFull.Broadcast()
+}
+
+func (c *Chan[T]) checkLane(lane int) {
+	if lane < 0 || lane >= len(c.lanes) {
+		panic(fmt.Sprintf("prioritychan: 车道 %d 超出范围 [0, %d)", lane, len(c.lanes)))
+	}
+}
+
+// push 放入元素（调用方持有 c.mu）
+func (c *Chan[T]) push(lane int, v T) {
+	if c.closed {
+		panic("prioritychan: send on closed channel")
+	}
+	c.lanes[lane] = append(c.lanes[lane], v)
+	c.notEmpty.Signal()
+}
+
+// pick 选出下一次接收的车道，所有车道为空时返回 -1（调用方持有 c.mu）
+func (c *Chan[T]) pick() int {
+	chosen := -1
+	for i, lane := range c.lanes {
+		if len(lane) == 0 {
+			continue
+		}
+		if c.guard > 0 && c.skipped[i] >= c.guard {
+			chosen = i // 已饥饿的车道中优先级最高的
+			break
+		}
+		if chosen < 0 {
+			chosen = i
+		}
+	}
+	if chosen < 0 {
+		return -1
+	}
+	// 比被选中车道优先级低、且有元素在等的车道都被插队了一次
+	for i := chosen + 1; i < len(c.lanes); i++ {
+		if len(c.lanes[i]) > 0 {
+			c.skipped[i]++
+		}
+	}
+	c.skipped[chosen] = 0
+	return chosen
+}
+
+// pop 取出车道队头（调用方持有 c.mu）
+func (c *Chan[T]) pop(lane int) T {
+	var zero T
+	v := c.lanes[lane][0]
+	c.lanes[lane][0] = zero
+	c.lanes[lane] = c.lanes[lane][1:]
+	c.notFull.Broadcast() // 不同车道的发送方等在同一个条件变量上
+	return v
+}
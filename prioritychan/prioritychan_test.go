@@ -0,0 +1,164 @@
+package prioritychan
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// drain 非阻塞地取出所有元素，返回各元素所在的车道
+func drain[T any](c *Chan[T]) []int {
+	var lanes []int
+	for c.Len() > 0 {
+		_, lane, _ := c.RecvLane()
+		lanes = append(lanes, lane)
+	}
+	return lanes
+}
+
+func TestLanePriority(t *testing.T) {
+	c := New[string](3, 4)
+	c.Send(2, "低1")
+	c.Send(1, "中1")
+	c.Send(2, "低2")
+	c.Send(0, "高1")
+	c.Send(1, "中2")
+
+	var got []string
+	for c.Len() > 0 {
+		v, _ := c.Recv()
+		got = append(got, v)
+	}
+	// 高优先级先出，同一车道内 FIFO
+	if want := []string{"高1", "中1", "中2", "低1", "低2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, ok := c.TryRecv(); ok {
+		t.Error("TryRecv on empty channel returned ok")
+	}
+}
+
+func TestStrictPriorityStarves(t *testing.T) {
+	c := New[int](2, 10)
+	for range 10 {
+		c.Send(0, 0)
+	}
+	c.Send(1, 1)
+	// 没有饥饿保护：低优先级车道排在所有高优先级元素之后
+	if got := drain(c); got[len(got)-1] != 1 || got[len(got)-2] != 0 {
+		t.Errorf("lanes = %v, want lane 1 last", got)
+	}
+}
+
+func TestStarvationGuard(t *testing.T) {
+	c := New[int](3, 10).WithStarvationGuard(3)
+	for range 10 {
+		c.Send(0, 0)
+	}
+	c.Send(1, 1)
+	c.Send(2, 2)
+
+	// 车道 1 和 2 每被插队 3 次就服务一次，同时饥饿时先服务车道 1
+	want := []int{0, 0, 0, 1, 2, 0, 0, 0, 0, 0, 0, 0}
+	if got := drain(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("lanes = %v, want %v", got, want)
+	}
+}
+
+func TestSendBlocksWhenLaneFull(t *testing.T) {
+	c := New[int](2, 1)
+	c.Send(0, 1)
+	if c.TrySend(0, 2) {
+		t.Error("TrySend succeeded on a full lane")
+	}
+	if !c.TrySend(1, 3) {
+		t.Error("TrySend failed on an empty lane")
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		c.Send(0, 2)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("Send did not block on a full lane")
+	case <-time.After(20 * time.Millisecond):
+	}
+	c.Recv()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("Send still blocked after the lane was drained")
+	}
+}
+
+func TestClose(t *testing.T) {
+	c := New[int](2, 4)
+	c.Send(1, 1)
+	c.Send(0, 0)
+
+	received := make(chan []int)
+	go func() {
+		var lanes []int
+		for lane := range c.All() { // 关闭且取空后结束
+			lanes = append(lanes, lane)
+		}
+		received <- lanes
+	}()
+	c.Close()
+
+	select {
+	case got := <-received:
+		if want := []int{0, 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("All() yielded lanes %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("All() did not end after Close")
+	}
+	if v, ok := c.Recv(); ok || v != 0 {
+		t.Errorf("Recv after close = %v, %v, want 0, false", v, ok)
+	}
+
+	mustPanic(t, "Send after Close", func() { c.Send(0, 1) })
+	mustPanic(t, "TrySend after Close", func() { c.TrySend(0, 1) })
+	mustPanic(t, "double Close", c.Close)
+}
+
+func TestCloseWakesBlockedSender(t *testing.T) {
+	c := New[int](1, 1)
+	c.Send(0, 1)
+	panicked := make(chan bool)
+	go func() {
+		defer func() { panicked <- recover() != nil }()
+		c.Send(0, 2) // 车道已满，阻塞到 Close，然后像内置通道一样 panic
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	if !<-panicked {
+		t.Error("blocked Send did not panic after Close")
+	}
+}
+
+func TestAllBreak(t *testing.T) {
+	c := New[int](2, 4)
+	for i := range 4 {
+		c.Send(i%2, i)
+	}
+	for range c.All() {
+		break // 提前结束不影响剩余元素
+	}
+	if n := c.Len(); n != 3 {
+		t.Errorf("Len() after break = %d, want 3", n)
+	}
+}
+
+func mustPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: want panic", name)
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,246 @@
+package ringbuf
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// 基于 sync/atomic 的无锁环形缓冲区，用于通道成为瓶颈的热点路径。
+//   - SPSC：单生产者单消费者，只用两个原子游标，最快，但只能各有一个协程读写
+//   - MPMC：多生产者多消费者，每个槽位带序号（Dmitry Vyukov 的有界队列算法）
+// 两者都提供非阻塞（TryPush/TryPop）和阻塞（Push/Pop）两套接口。
+// 阻塞接口在等待时先自旋，再让出处理器（runtime.Gosched），不会真正挂起协程，
+// 所以适合生产、消费速度接近的场景；长时间空闲的队列仍然应该用通道。
+// 容量会向上取整为 2 的幂，便于用位运算代替取模。
+
+// cacheLinePad 填充一个缓存行（64 字节），让生产者游标和消费者游标不在同一缓存行上，避免伪共享
+type cacheLinePad [64]byte
+
+// spinLimit 阻塞接口在让出处理器前的自旋次数
+const spinLimit = 32
+
+// roundUp 返回不小于 n 的最小 2 的幂
+func roundUp(n int) uint64 {
+	size := uint64(1)
+	for size < uint64(n) {
+		size <<= 1
+	}
+	return size
+}
+
+// wait 第 i 次重试前的等待：先空转，再让出处理器
+func wait(i int) {
+	if i >= spinLimit {
+		runtime.Gosched()
+	}
+}
+
+// SPSC 单生产者单消费者环形缓冲区。
+// Push/TryPush 只能由同一个协程调用，Pop/TryPop 只能由另一个（同一个）协程调用。
+type SPSC[T any] struct {
+	_      cacheLinePad
+	head   atomic.Uint64 // 下一个要读的位置，只由消费者写
+	_      cacheLinePad
+	tail   atomic.Uint64 // 下一个要写的位置，只由生产者写
+	_      cacheLinePad
+	closed atomic.Bool
+	mask   uint64
+	buf    []T
+}
+
+// NewSPSC 创建容量至少为 capacity 的 SPSC 缓冲区
+func NewSPSC[T any](capacity int) *SPSC[T] {
+	size := roundUp(max(capacity, 1))
+	return &SPSC[T]{mask: size - 1, buf: make([]T, size)}
+}
+
+// Cap 返回实际容量
+func (q *SPSC[T]) Cap() int {
+	return len(q.buf)
+}
+
+// Len 返回当前元素数（并发读写时只是近似值）
+func (q *SPSC[T]) Len() int {
+	return int(q.tail.Load() - q.head.Load())
+}
+
+// TryPush 放入 v，缓冲区已满或已关闭时返回 false
+func (q *SPSC[T]) TryPush(v T) bool {
+	if q.closed.Load() {
+		return false
+	}
+	t := q.tail.Load()
+	if t-q.head.Load() == uint64(len(q.buf)) {
+		return false
+	}
+	q.buf[t&q.mask] = v
+	q.tail.Store(t + 1) // 发布：消费者读到新的 tail 后一定能看到 buf 中的值
+	return true
+}
+
+// TryPop 取出一个元素，缓冲区为空时返回 false
+func (q *SPSC[T]) TryPop() (T, bool) {
+	var zero T
+	h := q.head.Load()
+	if h == q.tail.Load() {
+		return zero, false
+	}
+	v := q.buf[h&q.mask]
+	q.buf[h&q.mask] = zero // 不再引用已取出的值，便于 GC
+	q.head.Store(h + 1)
+	return v, true
+}
+
+// Push 放入 v，缓冲区已满时等待；已关闭时返回 false
+func (q *SPSC[T]) Push(v T) bool {
+	for i := 0; ; i++ {
+		if q.TryPush(v) {
+			return true
+		}
+		if q.closed.Load() {
+			return false
+		}
+		wait(i)
+	}
+}
+
+// Pop 取出一个元素，缓冲区为空时等待；已关闭且取空后返回 false
+func (q *SPSC[T]) Pop() (T, bool) {
+	for i := 0; ; i++ {
+		if v, ok := q.TryPop(); ok {
+			return v, true
+		}
+		if q.closed.Load() {
+			// 关闭前放入的元素必须先取完
+			return q.TryPop()
+		}
+		wait(i)
+	}
+}
+
+// Close 关闭缓冲区：之后的 Push 失败，Pop 取完剩余元素后返回 false
+func (q *SPSC[T]) Close() {
+	q.closed.Store(true)
+}
+
+// slot MPMC 的槽位，seq 表示该槽位当前可写（seq == pos）还是可读（seq == pos+1）
+type slot[T any] struct {
+	seq atomic.Uint64
+	val T
+}
+
+// MPMC 多生产者多消费者环形缓冲区
+type MPMC[T any] struct {
+	_      cacheLinePad
+	head   atomic.Uint64
+	_      cacheLinePad
+	tail   atomic.Uint64
+	_      cacheLinePad
+	closed atomic.Bool
+	mask   uint64
+	slots  []slot[T]
+}
+
+// NewMPMC 创建容量至少为 capacity 的 MPMC 缓冲区（至少为 2）
+func NewMPMC[T any](capacity int) *MPMC[T] {
+	size := roundUp(max(capacity, 2))
+	q := &MPMC[T]{mask: size - 1, slots: make([]slot[T], size)}
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// Cap 返回实际容量
+func (q *MPMC[T]) Cap() int {
+	return len(q.slots)
+}
+
+// Len 返回当前元素数（并发读写时只是近似值）
+func (q *MPMC[T]) Len() int {
+	return int(q.tail.Load() - q.head.Load())
+}
+
+// TryPush 放入 v，缓冲区已满或已关闭时返回 false
+func (q *MPMC[T]) TryPush(v T) bool {
+	if q.closed.Load() {
+		return false
+	}
+	pos := q.tail.Load()
+	for {
+		s := &q.slots[pos&q.mask]
+		seq := s.seq.Load()
+		switch diff := int64(seq) - int64(pos); {
+		case diff == 0:
+			// 槽位可写，抢占 tail
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				s.val = v
+				s.seq.Store(pos + 1) // 发布给消费者
+				return true
+			}
+			pos = q.tail.Load()
+		case diff < 0:
+			// 槽位里还是上一轮未被取走的元素：已满
+			return false
+		default:
+			// 其他生产者已抢先，重新读取 tail
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// TryPop 取出一个元素，缓冲区为空时返回 false
+func (q *MPMC[T]) TryPop() (T, bool) {
+	var zero T
+	pos := q.head.Load()
+	for {
+		s := &q.slots[pos&q.mask]
+		seq := s.seq.Load()
+		switch diff := int64(seq) - int64(pos+1); {
+		case diff == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				v := s.val
+				s.val = zero
+				s.seq.Store(pos + q.mask + 1) // 槽位留给下一轮的生产者
+				return v, true
+			}
+			pos = q.head.Load()
+		case diff < 0:
+			return zero, false
+		default:
+			pos = q.head.Load()
+		}
+	}
+}
+
+// Push 放入 v，缓冲区已满时等待；已关闭时返回 false
+func (q *MPMC[T]) Push(v T) bool {
+	for i := 0; ; i++ {
+		if q.TryPush(v) {
+			return true
+		}
+		if q.closed.Load() {
+			return false
+		}
+		wait(i)
+	}
+}
+
+// Pop 取出一个元素，缓冲区为空时等待；已关闭且取空后返回 false。
+// 注意：与 Close 同时进行的 Push 可能在 Pop 认为已取空之后才放入，调用方应在所有生产者结束后再 Close
+func (q *MPMC[T]) Pop() (T, bool) {
+	for i := 0; ; i++ {
+		if v, ok := q.TryPop(); ok {
+			return v, true
+		}
+		if q.closed.Load() {
+			return q.TryPop()
+		}
+		wait(i)
+	}
+}
+
+// Close 关闭缓冲区：之后的 Push 失败，Pop 取完剩余元素后返回 false
+func (q *MPMC[T]) Close() {
+	q.closed.Store(true)
+}
@@ -0,0 +1,239 @@
+package ringbuf
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSPSCTry(t *testing.T) {
+	q := NewSPSC[int](3)
+	if q.Cap() != 4 {
+		t.Fatalf("Cap = %d, want 4", q.Cap())
+	}
+	for i := range 4 {
+		if !q.TryPush(i) {
+			t.Fatalf("TryPush(%d) 失败", i)
+		}
+	}
+	if q.TryPush(4) {
+		t.Error("已满时 TryPush 成功")
+	}
+	for i := range 4 {
+		if v, ok := q.TryPop(); !ok || v != i {
+			t.Fatalf("TryPop = %d, %v, want %d, true", v, ok, i)
+		}
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("为空时 TryPop 成功")
+	}
+}
+
+func TestMPMCTry(t *testing.T) {
+	q := NewMPMC[int](4)
+	for i := range 4 {
+		if !q.TryPush(i) {
+			t.Fatalf("TryPush(%d) 失败", i)
+		}
+	}
+	if q.TryPush(4) {
+		t.Error("已满时 TryPush 成功")
+	}
+	// 绕过一圈后槽位仍然可用
+	for round := range 3 {
+		for i := range 4 {
+			if v, ok := q.TryPop(); !ok || v != round*4+i {
+				t.Fatalf("TryPop = %d, %v, want %d, true", v, ok, round*4+i)
+			}
+		}
+		for i := range 4 {
+			q.TryPush((round+1)*4 + i)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	q := NewMPMC[int](4)
+	q.Push(1)
+	q.Push(2)
+	q.Close()
+	if q.Push(3) {
+		t.Error("关闭后 Push 成功")
+	}
+	for _, want := range []int{1, 2} {
+		if v, ok := q.Pop(); !ok || v != want {
+			t.Fatalf("Pop = %d, %v, want %d, true", v, ok, want)
+		}
+	}
+	if _, ok := q.Pop(); ok {
+		t.Error("关闭且取空后 Pop 成功")
+	}
+}
+
+// stressItems 压力测试中每个生产者放入的元素数，-race 下也能在几秒内完成
+const stressItems = 20000
+
+func TestSPSCStress(t *testing.T) {
+	q := NewSPSC[int](16)
+	go func() {
+		for i := range stressItems {
+			q.Push(i)
+		}
+		q.Close()
+	}()
+
+	next := 0
+	for {
+		v, ok := q.Pop()
+		if !ok {
+			break
+		}
+		if v != next {
+			t.Fatalf("乱序：收到 %d，期望 %d", v, next)
+		}
+		next++
+	}
+	if next != stressItems {
+		t.Errorf("收到 %d 个元素，期望 %d", next, stressItems)
+	}
+}
+
+func TestMPMCStress(t *testing.T) {
+	for _, tt := range []struct{ producers, consumers int }{{1, 1}, {4, 1}, {1, 4}, {4, 4}, {8, 8}} {
+		t.Run(fmt.Sprintf("%dP%dC", tt.producers, tt.consumers), func(t *testing.T) {
+			q := NewMPMC[int](16)
+			var producers sync.WaitGroup
+			for p := range tt.producers {
+				producers.Add(1)
+				go func() {
+					defer producers.Done()
+					for i := range stressItems {
+						q.Push(p*stressItems + i)
+					}
+				}()
+			}
+
+			// 每个元素恰好被取到一次；同一生产者的元素在每个消费者看来保持顺序
+			seen := make([]atomic.Int32, tt.producers*stressItems)
+			var consumers sync.WaitGroup
+			for range tt.consumers {
+				consumers.Add(1)
+				go func() {
+					defer consumers.Done()
+					last := make([]int, tt.producers)
+					for i := range last {
+						last[i] = -1
+					}
+					for {
+						v, ok := q.Pop()
+						if !ok {
+							return
+						}
+						seen[v].Add(1)
+						p, i := v/stressItems, v%stressItems
+						if i <= last[p] {
+							t.Errorf("生产者 %d 的元素乱序：%d 在 %d 之后", p, i, last[p])
+						}
+						last[p] = i
+					}
+				}()
+			}
+
+			producers.Wait()
+			q.Close()
+			consumers.Wait()
+			for v := range seen {
+				if n := seen[v].Load(); n != 1 {
+					t.Fatalf("元素 %d 被取到 %d 次", v, n)
+				}
+			}
+		})
+	}
+}
+
+// 基准测试：相同容量下比较环形缓冲区与缓冲通道，b.N 为元素总数，由生产者平分
+
+const benchCapacity = 1024
+
+var benchCounts = []struct{ producers, consumers int }{{1, 1}, {2, 2}, {4, 1}, {1, 4}, {4, 4}, {8, 8}}
+
+// runBench 启动生产者和消费者，直到 b.N 个元素全部被取走
+func runBench(b *testing.B, producers, consumers int, push func(int), pop func() bool, close func()) {
+	per := b.N / producers
+	var prod, cons sync.WaitGroup
+	b.ResetTimer()
+	for p := range producers {
+		n := per
+		if p == 0 {
+			n += b.N % producers
+		}
+		prod.Add(1)
+		go func() {
+			defer prod.Done()
+			for i := range n {
+				push(i)
+			}
+		}()
+	}
+	for range consumers {
+		cons.Add(1)
+		go func() {
+			defer cons.Done()
+			for pop() {
+			}
+		}()
+	}
+	prod.Wait()
+	close()
+	cons.Wait()
+}
+
+func BenchmarkSPSC(b *testing.B) {
+	b.Run("ringbuf", func(b *testing.B) {
+		q := NewSPSC[int](benchCapacity)
+		runBench(b, 1, 1, func(v int) { q.Push(v) }, func() bool { _, ok := q.Pop(); return ok }, q.Close)
+	})
+	b.Run("chan", func(b *testing.B) {
+		ch := make(chan int, benchCapacity)
+		runBench(b, 1, 1, func(v int) { ch <- v }, func() bool { _, ok := <-ch; return ok }, func() { close(ch) })
+	})
+}
+
+func BenchmarkMPMC(b *testing.B) {
+	for _, c := range benchCounts {
+		name := fmt.Sprintf("%dP%dC", c.producers, c.consumers)
+		b.Run(name+"/ringbuf", func(b *testing.B) {
+			q := NewMPMC[int](benchCapacity)
+			runBench(b, c.producers, c.consumers, func(v int) { q.Push(v) }, func() bool { _, ok := q.Pop(); return ok }, q.Close)
+		})
+		b.Run(name+"/chan", func(b *testing.B) {
+			ch := make(chan int, benchCapacity)
+			runBench(b, c.producers, c.consumers, func(v int) { ch <- v }, func() bool { _, ok := <-ch; return ok }, func() { close(ch) })
+		})
+	}
+}
+
+func BenchmarkTry(b *testing.B) {
+	// 单协程下的非阻塞放入/取出，衡量没有竞争时的固定开销
+	b.Run("ringbuf", func(b *testing.B) {
+		q := NewMPMC[int](benchCapacity)
+		for i := range b.N {
+			q.TryPush(i)
+			q.TryPop()
+		}
+	})
+	b.Run("chan", func(b *testing.B) {
+		ch := make(chan int, benchCapacity)
+		for i := range b.N {
+			select {
+			case ch <- i:
+			default:
+			}
+			select {
+			case <-ch:
+			default:
+			}
+		}
+	})
+}
@@ -0,0 +1,489 @@
+package spillqueue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 溢出到磁盘的队列：用法像通道（Send 发送，range Out() 接收），
+// 但内存缓冲满了不会阻塞生产者，而是把后续元素追加到磁盘上的段文件中。
+// 顺序保证（FIFO）：只要磁盘上还有元素，新元素一律写磁盘，
+// 因此内存中的元素总是比磁盘上的旧；内存取空后再从最旧的段文件加载。
+// 段文件是 JSON-lines 格式，每个文件最多 SegmentSize 个元素。
+// 溢出的元素在 Send 返回前写入段文件（不调用 fsync），进程崩溃不会丢失，机器掉电则可能丢失。
+//
+// 两种关闭方式：
+//   - Close 立即关闭 Out，把内存中尚未取走的元素写回磁盘，下次 Open 同一目录即可按原顺序继续消费；
+//   - Drain 不再接收新元素，等消费者取完内存和磁盘中的全部元素后再关闭 Out。
+// 从段文件加载到内存的元素全部交给消费者后才删除该段文件，已交出的个数记录在旁边的 .ack 文件中，
+// 崩溃后重新 Open 从记录的位置继续（交出后、记录前崩溃的那一个元素会再投递一次）。
+// 注意进程崩溃时直接放入内存缓冲、从未溢出的元素（最多 MemoryCapacity 个）会丢失。
+// 段文件损坏时 Out 提前关闭，错误通过 Err 获取，剩余数据留在磁盘上。
+
+// ErrClosed 队列已关闭
+var ErrClosed = errors.New("spillqueue: 已关闭")
+
+const segmentExt = ".seg"
+
+// firstSegment 新目录的第一个段号。Close 写回内存元素时使用比现有段更小的段号，因此留出余量
+const firstSegment = 1 << 32
+
+// Options 队列配置
+type Options struct {
+	MemoryCapacity int // 内存缓冲能容纳的元素数，默认 1024
+	SegmentSize    int // 每个段文件的元素数，默认与 MemoryCapacity 相同
+}
+
+// Depth 队列深度
+type Depth struct {
+	Memory int // 内存中的元素数
+	Disk   int // 磁盘段文件中的元素数
+}
+
+// segment 一个段文件
+type segment struct {
+	seq   int64
+	count int // 尚未交给消费者的元素数
+	acked int // 已交给消费者的元素数，即 .ack 文件中记录的读取位置
+}
+
+// Queue 溢出到磁盘的 FIFO 队列
+type Queue[T any] struct {
+	dir  string
+	opts Options
+
+	mu       sync.Mutex
+	cond     *sync.Cond // 有新元素或队列关闭时广播
+	memory   []T        // 内存缓冲，队头最旧
+	segments []segment  // 磁盘段，按段号升序，最后一个是正在追加的段
+	tail     *os.File   // 正在追加的段文件
+	head     *segment   // 已加载到内存、尚未全部交出的段，其文件仍在磁盘上
+	pending  int        // head 中尚未交出的元素数，它们位于 memory 队头（含泵协程手上的一个）
+	ackFile  *os.File   // head 的 .ack 文件
+	closed   bool
+	draining bool  // Drain 中：不再接收新元素，取完后关闭 Out
+	err      error // 泵协程遇到的错误，见 Err
+
+	out  chan T
+	stop chan struct{} // Close 时关闭，通知泵协程退出
+	done chan struct{} // 泵协程退出后关闭
+}
+
+// Open 打开（或创建）dir 目录下的队列，并恢复上次 Close 时留下的元素
+func Open[T any](dir string, opts Options) (*Queue[T], error) {
+	if opts.MemoryCapacity <= 0 {
+		opts.MemoryCapacity = 1024
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = opts.MemoryCapacity
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	q := &Queue[T]{
+		dir:  dir,
+		opts: opts,
+		out:  make(chan T),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	if err := q.recover(); err != nil {
+		return nil, err
+	}
+	go q.pump()
+	return q, nil
+}
+
+// Send 把 v 放入队列，内存缓冲满时写入磁盘，不会因容量阻塞
+func (q *Queue[T]) Send(v T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.draining {
+		return ErrClosed
+	}
+
+	if len(q.segments) == 0 && len(q.memory) < q.opts.MemoryCapacity {
+		q.memory = append(q.memory, v)
+	} else if err := q.spill(v); err != nil {
+		return err
+	}
+	q.cond.Broadcast()
+	return nil
+}
+
+// Out 接收端，Close、Drain 完成或泵协程出错后关闭
+func (q *Queue[T]) Out() <-chan T {
+	return q.out
+}
+
+// Err 返回导致 Out 提前关闭的错误（例如段文件损坏），没有错误时返回 nil
+func (q *Queue[T]) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
+
+// Depth 返回内存与磁盘中的元素数
+func (q *Queue[T]) Depth() Depth {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	d := Depth{Memory: len(q.memory)}
+	for _, seg := range q.segments {
+		d.Disk += seg.count
+	}
+	return d
+}
+
+// Close 停止接收新元素并立即关闭 Out，内存中剩余的元素写回磁盘而不是交给消费者。
+// 下次 Open 同一目录时，这些元素会按原顺序继续被消费。需要先取完再关闭时使用 Drain。
+func (q *Queue[T]) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	close(q.stop)
+
+	<-q.done // 等泵协程把手上的元素放回内存
+	return q.persist()
+}
+
+// Drain 停止接收新元素，等消费者从 Out 取完内存和磁盘中的全部元素后关闭 Out，返回 Err()。
+// ctx 取消时改为 Close：尚未取走的元素写回磁盘，返回 ctx.Err()。
+func (q *Queue[T]) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed || q.draining {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	q.draining = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+	case <-ctx.Done():
+		if err := q.Close(); err != nil && !errors.Is(err, ErrClosed) {
+			return err
+		}
+		return ctx.Err()
+	}
+
+	q.mu.Lock()
+	if q.closed { // 等待期间 Close 已经接管
+		q.mu.Unlock()
+		return q.Err()
+	}
+	q.closed = true
+	q.mu.Unlock()
+	close(q.stop)
+
+	// 正常取完时内存和磁盘都已为空；泵协程出错时把剩余元素留在磁盘上
+	if err := q.persist(); err != nil {
+		return err
+	}
+	return q.Err()
+}
+
+// persist 关闭正在追加的段文件，并把内存中的元素写回磁盘（泵协程已退出）
+func (q *Queue[T]) persist() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.closeTail(); err != nil {
+		return err
+	}
+	return q.persistMemory()
+}
+
+// pump 把队头元素逐个送到 out
+func (q *Queue[T]) pump() {
+	defer close(q.done)
+	defer close(q.out)
+
+	for {
+		q.mu.Lock()
+		for len(q.memory) == 0 && len(q.segments) == 0 && !q.closed && !q.draining {
+			q.cond.Wait()
+		}
+		if q.closed || (len(q.memory) == 0 && len(q.segments) == 0) { // 关闭，或 Drain 已取完
+			q.mu.Unlock()
+			return
+		}
+		if len(q.memory) == 0 {
+			if err := q.load(); err != nil {
+				// 段文件损坏时无法继续保证顺序，停止输出，剩余数据留在磁盘上
+				q.err = err
+				q.mu.Unlock()
+				return
+			}
+		}
+		v := q.memory[0]
+		q.memory = q.memory[1:]
+		q.mu.Unlock()
+
+		// 等待消费者时可能被关闭，此时把元素放回队头
+		select {
+		case q.out <- v:
+		case <-q.stop:
+			q.mu.Lock()
+			q.memory = append([]T{v}, q.memory...)
+			q.mu.Unlock()
+			return
+		}
+
+		q.mu.Lock()
+		err := q.ack()
+		if err != nil {
+			q.err = err
+		}
+		q.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ack 记录 head 中又有一个元素交给了消费者，全部交出后删除段文件（调用方持有 q.mu）
+func (q *Queue[T]) ack() error {
+	if q.pending == 0 {
+		return nil
+	}
+	q.pending--
+	q.head.acked++
+	if q.pending > 0 {
+		_, err := q.ackFile.WriteAt([]byte(fmt.Sprintf("%020d\n", q.head.acked)), 0)
+		return err
+	}
+	return q.dropHead()
+}
+
+// dropHead 删除 head 的段文件和 .ack 文件（调用方持有 q.mu）
+func (q *Queue[T]) dropHead() error {
+	seq := q.head.seq
+	q.head, q.pending = nil, 0
+	if q.ackFile != nil {
+		q.ackFile.Close()
+		q.ackFile = nil
+	}
+	if err := os.Remove(q.path(seq)); err != nil {
+		return err
+	}
+	if err := os.Remove(q.ackPath(seq)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// spill 把 v 追加到最新的段文件，段满时新建段（调用方持有 q.mu）
+func (q *Queue[T]) spill(v T) error {
+	if q.tail == nil || q.segments[len(q.segments)-1].count >= q.opts.SegmentSize {
+		if err := q.closeTail(); err != nil {
+			return err
+		}
+		seq := int64(firstSegment)
+		if n := len(q.segments); n > 0 {
+			seq = q.segments[n-1].seq + 1
+		}
+		f, err := os.OpenFile(q.path(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		q.tail = f
+		q.segments = append(q.segments, segment{seq: seq})
+	}
+
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// 直接写文件而不经过缓冲，Send 返回后元素即使进程崩溃也不会丢失
+	if _, err := q.tail.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	q.segments[len(q.segments)-1].count++
+	return nil
+}
+
+// load 把最旧的段文件中尚未交出的元素读入内存。段文件保留到这些元素全部交给消费者（调用方持有 q.mu）
+func (q *Queue[T]) load() error {
+	seg := q.segments[0]
+	if len(q.segments) == 1 {
+		// 正在追加的段：先关闭，之后的 Send 会新建段
+		if err := q.closeTail(); err != nil {
+			return err
+		}
+	}
+	items, err := readSegment[T](q.path(seg.seq))
+	if err != nil {
+		return err
+	}
+	if seg.acked > len(items) {
+		return fmt.Errorf("%s: 读取位置 %d 超过元素数 %d", q.ackPath(seg.seq), seg.acked, len(items))
+	}
+	items = items[seg.acked:]
+	ackFile, err := os.OpenFile(q.ackPath(seg.seq), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	q.segments = q.segments[1:]
+	q.head, q.pending, q.ackFile = &seg, len(items), ackFile
+	q.memory = append(q.memory, items...)
+	if q.pending == 0 {
+		return q.dropHead()
+	}
+	return nil
+}
+
+// persistMemory 把内存中的元素写到比现有段更早的段文件（调用方持有 q.mu）。
+// 内存队头是 head 中尚未交出的元素时，直接用内存中的全部元素替换 head 的段文件
+func (q *Queue[T]) persistMemory() error {
+	if len(q.memory) == 0 {
+		return nil
+	}
+	seq := int64(firstSegment)
+	if len(q.segments) > 0 {
+		seq = q.segments[0].seq - 1
+	}
+	if q.head != nil {
+		seq = q.head.seq
+		// 先删除读取位置再替换段文件：两步之间崩溃只会重复投递已交出的元素，不会跳过未交出的
+		q.ackFile.Close()
+		q.ackFile = nil
+		if err := os.Remove(q.ackPath(seq)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		q.head, q.pending = nil, 0
+	}
+
+	tmp := q.path(seq) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, v := range q.memory {
+		if err := enc.Encode(v); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, q.path(seq)); err != nil {
+		return err
+	}
+	q.segments = append([]segment{{seq: seq, count: len(q.memory)}}, q.segments...)
+	q.memory = nil
+	return nil
+}
+
+// recover 扫描目录中已有的段文件和读取位置
+func (q *Queue[T]) recover() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		items, err := readSegment[T](filepath.Join(q.dir, name))
+		if err != nil {
+			return err
+		}
+		acked, err := readAck(q.ackPath(seq))
+		if err != nil {
+			return err
+		}
+		if acked >= len(items) { // 全部交出后、删除前崩溃
+			os.Remove(q.path(seq))
+			os.Remove(q.ackPath(seq))
+			continue
+		}
+		q.segments = append(q.segments, segment{seq: seq, count: len(items) - acked, acked: acked})
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].seq < q.segments[j].seq
+	})
+	return nil
+}
+
+// closeTail 关闭正在追加的段文件（调用方持有 q.mu）
+func (q *Queue[T]) closeTail() error {
+	if q.tail == nil {
+		return nil
+	}
+	err := q.tail.Close()
+	q.tail = nil
+	return err
+}
+
+func (q *Queue[T]) path(seq int64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// ackPath 段文件的读取位置文件
+func (q *Queue[T]) ackPath(seq int64) string {
+	return q.path(seq) + ".ack"
+}
+
+// readAck 读取已交出的元素数，文件不存在时为 0
+func readAck(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(b) == 0) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// readSegment 读取段文件中的全部元素
+func readSegment[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []T
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
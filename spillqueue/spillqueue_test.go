@@ -0,0 +1,240 @@
+package spillqueue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sendAll 依次发送 from..to-1
+func sendAll(t *testing.T, q *Queue[int], from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if err := q.Send(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// receive 从 Out 接收 n 个元素
+func receive(t *testing.T, q *Queue[int], n int) []int {
+	t.Helper()
+	got := make([]int, 0, n)
+	for range n {
+		select {
+		case v, ok := <-q.Out():
+			if !ok {
+				t.Fatalf("Out closed after %d items: %v", len(got), q.Err())
+			}
+			got = append(got, v)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d items", len(got))
+		}
+	}
+	return got
+}
+
+func seq(from, to int) []int {
+	s := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+func TestFIFOAcrossSpill(t *testing.T) {
+	q, err := Open[int](t.TempDir(), Options{MemoryCapacity: 4, SegmentSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	// 消费者还没开始接收：泵协程手上 1 个，内存 4 个，其余溢出到 4 个段
+	sendAll(t, q, 0, 16)
+	if d := q.Depth(); d.Disk == 0 {
+		t.Fatalf("depth = %+v, want spilled items", d)
+	}
+
+	// 边消费边发送，新元素必须排在磁盘上的旧元素之后
+	got := receive(t, q, 8)
+	sendAll(t, q, 16, 24)
+	got = append(got, receive(t, q, 16)...)
+	if want := seq(0, 24); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if d := q.Depth(); d != (Depth{}) {
+		t.Errorf("depth after draining = %+v, want empty", d)
+	}
+}
+
+func TestRecoverAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{MemoryCapacity: 4, SegmentSize: 3}
+
+	q, err := Open[int](dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendAll(t, q, 0, 20)
+	got := receive(t, q, 6) // 已经加载过段文件，内存和磁盘中都还有元素
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-q.Out(); ok {
+		t.Error("Out not closed after Close")
+	}
+	if err := q.Send(99); !errors.Is(err, ErrClosed) {
+		t.Errorf("Send after Close = %v, want ErrClosed", err)
+	}
+
+	q, err = Open[int](dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if d := q.Depth(); d.Memory+d.Disk != 14 {
+		t.Errorf("recovered depth = %+v, want 14 items", d)
+	}
+	sendAll(t, q, 20, 25)
+	got = append(got, receive(t, q, 19)...)
+	if want := seq(0, 25); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSpilledItemsSurviveCrash(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open[int](dir, Options{MemoryCapacity: 1, SegmentSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendAll(t, q, 0, 5)
+
+	// 不调用 Close，模拟进程崩溃：段文件未满，但 Send 返回前已经写入
+	items, err := readSegment[int](q.path(firstSegment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) == 0 {
+		t.Error("spilled items not written to disk before Send returned")
+	}
+	q.Close()
+}
+
+func TestCrashAfterPartialRead(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{MemoryCapacity: 3, SegmentSize: 4}
+	q, err := Open[int](dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendAll(t, q, 0, 13)
+	if err := q.Close(); err != nil { // 全部元素都在磁盘上
+		t.Fatal(err)
+	}
+
+	q, err = Open[int](dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, q, 6) // 已加载的段只交出了一部分
+	// 模拟崩溃：让泵协程退出，但不把内存中的元素写回磁盘
+	close(q.stop)
+	<-q.done
+
+	q, err = Open[int](dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if d := q.Depth(); d.Memory+d.Disk != 7 {
+		t.Errorf("recovered depth = %+v, want 7 items", d)
+	}
+	got = append(got, receive(t, q, 7)...)
+	if want := seq(0, 13); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	q, err := Open[int](t.TempDir(), Options{MemoryCapacity: 2, SegmentSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendAll(t, q, 0, 10)
+
+	done := make(chan error, 1)
+	go func() { done <- q.Drain(context.Background()) }()
+
+	var got []int
+	for v := range q.Out() { // Drain 取完后关闭 Out
+		got = append(got, v)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Drain() = %v", err)
+	}
+	if want := seq(0, 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if err := q.Send(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Send after Drain = %v, want ErrClosed", err)
+	}
+	if err := q.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Close after Drain = %v, want ErrClosed", err)
+	}
+}
+
+func TestDrainCanceled(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open[int](dir, Options{MemoryCapacity: 2, SegmentSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendAll(t, q, 0, 6)
+	got := receive(t, q, 2)
+
+	// 没有消费者，Drain 超时后改为 Close，剩余元素写回磁盘
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain() = %v, want DeadlineExceeded", err)
+	}
+
+	q, err = Open[int](dir, Options{MemoryCapacity: 2, SegmentSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	got = append(got, receive(t, q, 4)...)
+	if want := seq(0, 6); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open[int](dir, Options{MemoryCapacity: 1, SegmentSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	sendAll(t, q, 0, 6)
+
+	// 破坏还没有加载的最后一个段
+	if err := os.WriteFile(q.path(firstSegment+1), []byte("{坏数据\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for v := range q.Out() {
+		got = append(got, v)
+	}
+	if q.Err() == nil {
+		t.Fatal("Err() = nil after reading a corrupt segment")
+	}
+	if len(got) == 0 || len(got) >= 6 {
+		t.Errorf("got %v, want the items before the corrupt segment", got)
+	}
+}
@@ -0,0 +1,214 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed 资源池已关闭
+var ErrPoolClosed = errors.New("syncx: 资源池已关闭")
+
+// PoolOptions 资源池配置
+type PoolOptions[T any] struct {
+	New         func(ctx context.Context) (T, error) // 创建资源，必填
+	Close       func(T) error                        // 销毁资源，可选
+	Check       func(T) error                        // 借出空闲资源前的健康检查，返回错误时销毁并换一个，可选
+	MaxActive   int                                  // 同时借出的资源上限，默认 1
+	MaxIdle     int                                  // 最多保留的空闲资源数，默认等于 MaxActive
+	MaxLifetime time.Duration                        // 资源自创建起的最长使用时间，0 表示不限制
+}
+
+// PoolStats 资源池统计
+type PoolStats struct {
+	Active        int    // 当前借出的资源数
+	Idle          int    // 当前空闲的资源数
+	Created       uint64 // 累计创建数
+	Destroyed     uint64 // 累计销毁数
+	DestroyErrors uint64 // 销毁时 Close 返回错误的次数
+	Unhealthy     uint64 // 健康检查失败或被 Discard 的次数
+	Expired       uint64 // 超过 MaxLifetime 被销毁的次数
+	Waits         uint64 // 因借出数达到上限而等待的次数
+}
+
+// Pool 有界的泛型资源池（数据库连接、客户端等）。
+// 与 sync.Pool 不同，资源不会被悄悄丢弃：任何一个创建出来的资源要么空闲、要么借出、要么经过 Close 显式销毁，
+// 始终满足 Created = Active + Idle + Destroyed。
+type Pool[T any] struct {
+	opts PoolOptions[T]
+	sem  *Weighted // 借出名额
+
+	mu     sync.Mutex
+	idle   []*Resource[T] // 栈顶（末尾）是最近归还的
+	stats  PoolStats
+	closed bool
+}
+
+// Resource 借出的资源，用完必须调用 Release 或 Discard 之一（且只能一次）
+type Resource[T any] struct {
+	Value   T
+	pool    *Pool[T]
+	created time.Time
+	done    bool
+}
+
+// NewPool 创建资源池，资源在第一次借用时按需创建
+func NewPool[T any](opts PoolOptions[T]) *Pool[T] {
+	if opts.New == nil {
+		panic("syncx: PoolOptions.New 不能为空")
+	}
+	opts.MaxActive = max(opts.MaxActive, 1)
+	if opts.MaxIdle <= 0 {
+		opts.MaxIdle = opts.MaxActive
+	}
+	return &Pool[T]{opts: opts, sem: NewWeighted(int64(opts.MaxActive))}
+}
+
+// Get 借出一个资源：优先复用通过健康检查且未过期的空闲资源，否则创建新资源。
+// 借出数达到上限时等待，ctx 结束时返回 ctx.Err()
+func (p *Pool[T]) Get(ctx context.Context) (*Resource[T], error) {
+	if !p.sem.TryAcquire(1) {
+		p.mu.Lock()
+		p.stats.Waits++
+		p.mu.Unlock()
+		if err := p.sem.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			p.sem.Release(1)
+			return nil, ErrPoolClosed
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.stats.Active++ // 先占位，创建失败时再退回
+			p.mu.Unlock()
+			break
+		}
+		r := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.stats.Idle--
+		p.stats.Active++
+		p.mu.Unlock()
+
+		if p.expired(r) {
+			p.destroy(r, &p.stats.Expired)
+			continue
+		}
+		if p.opts.Check != nil && p.opts.Check(r.Value) != nil {
+			p.destroy(r, &p.stats.Unhealthy)
+			continue
+		}
+		r.done = false
+		return r, nil
+	}
+
+	v, err := p.opts.New(ctx)
+	p.mu.Lock()
+	if err != nil {
+		p.stats.Active--
+		p.mu.Unlock()
+		p.sem.Release(1)
+		return nil, err
+	}
+	p.stats.Created++
+	p.mu.Unlock()
+	return &Resource[T]{Value: v, pool: p, created: time.Now()}, nil
+}
+
+// Release 把资源还给池。池已关闭、空闲数已满或资源已过期时销毁它
+func (r *Resource[T]) Release() {
+	p := r.pool
+	r.finish()
+
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.opts.MaxIdle && !p.expired(r) {
+		p.idle = append(p.idle, r)
+		p.stats.Active--
+		p.stats.Idle++
+		p.mu.Unlock()
+		p.sem.Release(1)
+		return
+	}
+	expired := p.expired(r)
+	p.mu.Unlock()
+
+	if expired {
+		p.destroy(r, &p.stats.Expired)
+	} else {
+		p.destroy(r, nil)
+	}
+	p.sem.Release(1)
+}
+
+// Discard 销毁资源而不放回池中，用于使用中发现资源已损坏的情况
+func (r *Resource[T]) Discard() {
+	r.finish()
+	r.pool.destroy(r, &r.pool.stats.Unhealthy)
+	r.pool.sem.Release(1)
+}
+
+func (r *Resource[T]) finish() {
+	if r.done {
+		panic("syncx: 资源重复归还")
+	}
+	r.done = true
+}
+
+// Close 关闭资源池并销毁所有空闲资源；借出中的资源在归还时销毁。返回销毁时的错误
+func (p *Pool[T]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.stats.Idle = 0
+	p.stats.Active += len(idle) // destroy 按借出中的资源计数
+	p.mu.Unlock()
+
+	var errs []error
+	for _, r := range idle {
+		if err := p.destroy(r, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats 返回统计
+func (p *Pool[T]) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *Pool[T]) expired(r *Resource[T]) bool {
+	return p.opts.MaxLifetime > 0 && time.Since(r.created) >= p.opts.MaxLifetime
+}
+
+// destroy 销毁一个借出中（已计入 Active）的资源，reason 为要额外累加的原因计数
+func (p *Pool[T]) destroy(r *Resource[T], reason *uint64) error {
+	var err error
+	if p.opts.Close != nil {
+		err = p.opts.Close(r.Value)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Active--
+	p.stats.Destroyed++
+	if reason != nil {
+		*reason++
+	}
+	if err != nil {
+		p.stats.DestroyErrors++
+	}
+	return err
+}
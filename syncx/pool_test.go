@@ -0,0 +1,175 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// conn 测试用的资源
+type conn struct {
+	id     int64
+	broken atomic.Bool
+	closed atomic.Bool
+}
+
+// newTestPool 创建资源池，并返回所有创建过的资源以便检查
+func newTestPool(opts PoolOptions[*conn]) (*Pool[*conn], func() []*conn) {
+	var mu sync.Mutex
+	var all []*conn
+	var next atomic.Int64
+	opts.New = func(context.Context) (*conn, error) {
+		c := &conn{id: next.Add(1)}
+		mu.Lock()
+		all = append(all, c)
+		mu.Unlock()
+		return c, nil
+	}
+	opts.Close = func(c *conn) error {
+		if c.closed.Swap(true) {
+			return errors.New("重复关闭")
+		}
+		return nil
+	}
+	opts.Check = func(c *conn) error {
+		if c.broken.Load() {
+			return errors.New("连接已损坏")
+		}
+		return nil
+	}
+	return NewPool(opts), func() []*conn {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*conn(nil), all...)
+	}
+}
+
+func TestPoolReuse(t *testing.T) {
+	p, _ := newTestPool(PoolOptions[*conn]{MaxActive: 2})
+	ctx := context.Background()
+
+	r1, _ := p.Get(ctx)
+	id := r1.Value.id
+	r1.Release()
+	r2, _ := p.Get(ctx)
+	if r2.Value.id != id {
+		t.Errorf("没有复用空闲资源：%d, want %d", r2.Value.id, id)
+	}
+
+	// 健康检查失败的资源被销毁，换一个新的
+	r2.Value.broken.Store(true)
+	r2.Release()
+	r3, _ := p.Get(ctx)
+	if r3.Value.id == id {
+		t.Error("借出了未通过健康检查的资源")
+	}
+	r3.Release()
+
+	if s := p.Stats(); s.Created != 2 || s.Destroyed != 1 || s.Unhealthy != 1 || s.Idle != 1 || s.Active != 0 {
+		t.Errorf("Stats = %+v", s)
+	}
+}
+
+func TestPoolLimits(t *testing.T) {
+	p, all := newTestPool(PoolOptions[*conn]{MaxActive: 2, MaxIdle: 1, MaxLifetime: 20 * time.Millisecond})
+	ctx := context.Background()
+	r1, _ := p.Get(ctx)
+	r2, _ := p.Get(ctx)
+
+	// 借出数达到上限时等待
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+
+	// 空闲数超过 MaxIdle 的资源被销毁，而不是丢弃
+	r1.Release()
+	r2.Release()
+	if s := p.Stats(); s.Idle != 1 || s.Destroyed != 1 {
+		t.Errorf("Stats = %+v, want 1 个空闲、1 个销毁", s)
+	}
+
+	// 超过 MaxLifetime 的空闲资源不会被借出
+	time.Sleep(25 * time.Millisecond)
+	r3, _ := p.Get(ctx)
+	if r3.Value.closed.Load() {
+		t.Error("借出了已关闭的资源")
+	}
+	r3.Release()
+	if s := p.Stats(); s.Expired != 1 {
+		t.Errorf("Expired = %d, want 1", s.Expired)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get(ctx); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("err = %v, want ErrPoolClosed", err)
+	}
+	for _, c := range all() {
+		if !c.closed.Load() {
+			t.Errorf("资源 %d 关闭后没有被销毁", c.id)
+		}
+	}
+}
+
+func TestPoolStress(t *testing.T) {
+	const maxActive = 4
+	p, all := newTestPool(PoolOptions[*conn]{MaxActive: maxActive, MaxIdle: 2, MaxLifetime: 5 * time.Millisecond})
+	var active atomic.Int64
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 300 {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				r, err := p.Get(ctx)
+				cancel()
+				if err != nil {
+					continue
+				}
+				if n := active.Add(1); n > maxActive {
+					t.Errorf("同时借出 %d 个，超过上限 %d", n, maxActive)
+				}
+				if r.Value.closed.Load() {
+					t.Errorf("借出了已销毁的资源 %d", r.Value.id)
+				}
+				active.Add(-1)
+				switch (g + i) % 10 {
+				case 0:
+					r.Discard()
+				case 1:
+					r.Value.broken.Store(true)
+					r.Release()
+				default:
+					r.Release()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := p.Stats()
+	if s.Active != 0 {
+		t.Errorf("Active = %d, want 0", s.Active)
+	}
+	// 没有资源被悄悄丢弃：每个创建的资源要么空闲，要么已销毁
+	if s.Created != uint64(s.Idle)+s.Destroyed {
+		t.Errorf("Created %d != Idle %d + Destroyed %d", s.Created, s.Idle, s.Destroyed)
+	}
+	p.Close()
+	closed := 0
+	for _, c := range all() {
+		if c.closed.Load() {
+			closed++
+		}
+	}
+	if closed != len(all()) || s.DestroyErrors != 0 {
+		t.Errorf("%d 个资源中 %d 个被销毁，销毁错误 %d", len(all()), closed, s.DestroyErrors)
+	}
+}
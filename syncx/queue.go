@@ -0,0 +1,221 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 条件变量（sync.Cond）工具：锁笔记里说 Cond 适合"生产者-消费者"，这里给出三个例子。
+//   - BoundedQueue：有界阻塞队列，队列满时 Put 等待、空时 Take 等待
+//   - Latch：倒计数门闩，计数归零时一次性放行所有等待者
+//   - Barrier：N 个协程的循环屏障，最后一个到达时放行这一轮的所有协程，然后可以继续下一轮
+// sync.Cond 的 Wait 不能与 ctx.Done() 一起 select，这里用 context.AfterFunc 在 ctx 结束时广播，
+// 被唤醒的等待者检查 ctx.Err() 后退出。
+
+// ErrQueueClosed 队列已关闭（Put），或已关闭且取空（Take）
+var ErrQueueClosed = errors.New("syncx: 队列已关闭")
+
+// BoundedQueue 基于 sync.Cond 的有界阻塞队列。关闭语义与通道一致：关闭后不能再放入，剩余元素仍可取出
+type BoundedQueue[T any] struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	buf      []T // 环形缓冲
+	head     int
+	size     int
+	closed   bool
+}
+
+// NewBoundedQueue 创建容量为 capacity（至少为 1）的队列
+func NewBoundedQueue[T any](capacity int) *BoundedQueue[T] {
+	q := &BoundedQueue[T]{buf: make([]T, max(capacity, 1))}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// Put 放入 v，队列满时等待。ctx 结束返回 ctx.Err()，队列已关闭返回 ErrQueueClosed
+func (q *BoundedQueue[T]) Put(ctx context.Context, v T) error {
+	stop := wakeOnDone(ctx, &q.mu, q.notFull)
+	defer stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed && q.size == len(q.buf) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		q.notFull.Wait()
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+	q.buf[(q.head+q.size)%len(q.buf)] = v
+	q.size++
+	q.notEmpty.Signal()
+	return nil
+}
+
+// Take 取出队头，队列空时等待。ctx 结束返回 ctx.Err()，队列已关闭且取空返回 ErrQueueClosed
+func (q *BoundedQueue[T]) Take(ctx context.Context) (T, error) {
+	stop := wakeOnDone(ctx, &q.mu, q.notEmpty)
+	defer stop()
+
+	var zero T
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed && q.size == 0 {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		q.notEmpty.Wait()
+	}
+	if q.size == 0 {
+		return zero, ErrQueueClosed
+	}
+	v := q.buf[q.head]
+	q.buf[q.head] = zero
+	q.head = (q.head + 1) % len(q.buf)
+	q.size--
+	q.notFull.Signal()
+	return v, nil
+}
+
+// Len 返回当前元素数
+func (q *BoundedQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Cap 返回容量
+func (q *BoundedQueue[T]) Cap() int {
+	return len(q.buf)
+}
+
+// Close 关闭队列，唤醒所有等待者。重复关闭返回 ErrQueueClosed
+func (q *BoundedQueue[T]) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	return nil
+}
+
+// Latch 倒计数门闩：计数归零前 Wait 阻塞，归零后所有 Wait 立即返回，不能重置
+type Latch struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	count int
+}
+
+// NewLatch 创建初始计数为 count 的门闩
+func NewLatch(count int) *Latch {
+	l := &Latch{count: max(count, 0)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// CountDown 计数减一，归零时放行所有等待者；已归零时什么也不做
+func (l *Latch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		l.cond.Broadcast()
+	}
+}
+
+// Count 返回剩余计数
+func (l *Latch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Wait 等待计数归零，ctx 结束时返回 ctx.Err()
+func (l *Latch) Wait(ctx context.Context) error {
+	stop := wakeOnDone(ctx, &l.mu, l.cond)
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.count > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.cond.Wait()
+	}
+	return nil
+}
+
+// Barrier N 个协程的循环屏障：每一轮前 N-1 个调用 Await 的协程等待，第 N 个到达时放行这一轮，
+// 屏障随即重置，可以用于多个阶段的同步
+type Barrier struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	parties    int
+	arrived    int
+	generation uint64
+	action     func()
+}
+
+// NewBarrier 创建 parties 个协程的屏障。action 不为 nil 时，由每轮最后到达的协程在放行前执行
+func NewBarrier(parties int, action func()) *Barrier {
+	if parties <= 0 {
+		panic("syncx: 屏障的协程数必须大于 0")
+	}
+	b := &Barrier{parties: parties, action: action}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Await 到达屏障并等待本轮所有协程到达。
+// ctx 在本轮放行前结束时撤回这次到达并返回 ctx.Err()，不影响其他协程
+func (b *Barrier) Await(ctx context.Context) error {
+	stop := wakeOnDone(ctx, &b.mu, b.cond)
+	defer stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	gen := b.generation
+	b.arrived++
+	if b.arrived == b.parties {
+		if b.action != nil {
+			b.action()
+		}
+		b.arrived = 0
+		b.generation++
+		b.cond.Broadcast()
+		return nil
+	}
+	for gen == b.generation {
+		if err := ctx.Err(); err != nil {
+			b.arrived--
+			return err
+		}
+		b.cond.Wait()
+	}
+	return nil
+}
+
+// wakeOnDone 在 ctx 结束时广播 cond，让等待者有机会检查 ctx.Err()；返回取消注册的函数。
+// 广播前先获取锁，避免等待者检查完 ctx 还没进入 Wait 时错过这次唤醒
+func wakeOnDone(ctx context.Context, mu *sync.Mutex, cond *sync.Cond) (stop func() bool) {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+	return context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		cond.Broadcast()
+	})
+}
@@ -0,0 +1,233 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueue(t *testing.T) {
+	ctx := context.Background()
+	q := NewBoundedQueue[int](2)
+	q.Put(ctx, 1)
+	q.Put(ctx, 2)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.Put(timeout, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("队列满时 Put err = %v, want DeadlineExceeded", err)
+	}
+
+	// 关闭后不能放入，剩余元素仍可按顺序取出
+	q.Close()
+	if err := q.Put(ctx, 3); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("关闭后 Put err = %v, want ErrQueueClosed", err)
+	}
+	for _, want := range []int{1, 2} {
+		if v, err := q.Take(ctx); err != nil || v != want {
+			t.Fatalf("Take = %d, %v, want %d, nil", v, err, want)
+		}
+	}
+	if _, err := q.Take(ctx); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("取空后 Take err = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestBoundedQueueCancelTake(t *testing.T) {
+	q := NewBoundedQueue[int](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := q.Take(ctx)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want Canceled", err)
+	}
+}
+
+func TestBoundedQueueStress(t *testing.T) {
+	const producers, consumers, items = 4, 4, 2000
+	q := NewBoundedQueue[int](8)
+	ctx := context.Background()
+
+	var prod sync.WaitGroup
+	for p := range producers {
+		prod.Add(1)
+		go func() {
+			defer prod.Done()
+			for i := range items {
+				if err := q.Put(ctx, p*items+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	seen := make([]atomic.Int32, producers*items)
+	var cons sync.WaitGroup
+	for range consumers {
+		cons.Add(1)
+		go func() {
+			defer cons.Done()
+			for {
+				v, err := q.Take(ctx)
+				if err != nil {
+					return
+				}
+				seen[v].Add(1)
+			}
+		}()
+	}
+
+	prod.Wait()
+	q.Close()
+	cons.Wait()
+	for v := range seen {
+		if n := seen[v].Load(); n != 1 {
+			t.Fatalf("元素 %d 被取到 %d 次", v, n)
+		}
+	}
+}
+
+func TestLatch(t *testing.T) {
+	l := NewLatch(3)
+	var released atomic.Int32
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+			released.Add(1)
+		}()
+	}
+
+	l.CountDown()
+	l.CountDown()
+	time.Sleep(10 * time.Millisecond)
+	if n := released.Load(); n != 0 {
+		t.Fatalf("计数归零前放行了 %d 个协程", n)
+	}
+	l.CountDown()
+	wg.Wait()
+	l.CountDown() // 归零后再减不会变成负数
+	if l.Count() != 0 {
+		t.Errorf("Count = %d, want 0", l.Count())
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := NewLatch(1).Wait(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestBarrier(t *testing.T) {
+	const parties, rounds = 4, 50
+	var trips atomic.Int32
+	b := NewBarrier(parties, func() { trips.Add(1) })
+
+	// 每一轮所有协程都到达后才有人进入下一轮
+	var phase [rounds]atomic.Int32
+	var wg sync.WaitGroup
+	for range parties {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rounds {
+				phase[r].Add(1)
+				if err := b.Await(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+				if n := phase[r].Load(); n != parties {
+					t.Errorf("第 %d 轮只有 %d 个协程到达就被放行", r, n)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := trips.Load(); n != rounds {
+		t.Errorf("action 执行 %d 次, want %d", n, rounds)
+	}
+}
+
+func TestBarrierCancel(t *testing.T) {
+	b := NewBarrier(2, nil)
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Await(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+
+	// 撤回的到达不计入下一轮
+	done := make(chan error)
+	go func() { done <- b.Await(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("只有一个协程到达就被放行")
+	case <-time.After(10 * time.Millisecond):
+	}
+	b.Await(context.Background())
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+// 基准测试：与 four_channel.GetTwo 相同的容量 100 的生产者/消费者，b.N 为传递的元素总数
+
+const benchQueueCap = 100
+
+func BenchmarkQueue(b *testing.B) {
+	for _, n := range []int{1, 4} {
+		b.Run(fmt.Sprintf("%dP%dC/BoundedQueue", n, n), func(b *testing.B) {
+			q := NewBoundedQueue[int](benchQueueCap)
+			ctx := context.Background()
+			runProducerConsumer(b, n,
+				func(v int) { q.Put(ctx, v) },
+				func() bool { _, err := q.Take(ctx); return err == nil },
+				func() { q.Close() })
+		})
+		b.Run(fmt.Sprintf("%dP%dC/chan", n, n), func(b *testing.B) {
+			ch := make(chan int, benchQueueCap)
+			runProducerConsumer(b, n,
+				func(v int) { ch <- v },
+				func() bool { _, ok := <-ch; return ok },
+				func() { close(ch) })
+		})
+	}
+}
+
+func runProducerConsumer(b *testing.B, n int, put func(int), take func() bool, close func()) {
+	per := max(b.N/n, 1)
+	var prod, cons sync.WaitGroup
+	b.ResetTimer()
+	for range n {
+		prod.Add(1)
+		go func() {
+			defer prod.Done()
+			for i := range per {
+				put(i)
+			}
+		}()
+		cons.Add(1)
+		go func() {
+			defer cons.Done()
+			for take() {
+			}
+		}()
+	}
+	prod.Wait()
+	close()
+	cons.Wait()
+}
@@ -0,0 +1,110 @@
+package syncx
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// syncx 收集 five_mu 锁笔记里提到、但标准库没有直接提供的同步原语。
+
+// ErrTooLarge 请求的权重超过信号量的总容量，永远无法满足
+var ErrTooLarge = errors.New("syncx: 请求的权重超过信号量容量")
+
+// Weighted 带权重的信号量：总容量为 size，每次获取可以占用任意份额。
+// 等待者按先来后到排队，队头的大请求不会被后来的小请求一直插队（避免饿死）。
+type Weighted struct {
+	size    int64
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List // *waiter
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{} // 获取成功时关闭
+}
+
+// NewWeighted 创建总容量为 size 的信号量
+func NewWeighted(size int64) *Weighted {
+	return &Weighted{size: size}
+}
+
+// Acquire 获取 n 份额，不足时等待；ctx 结束时放弃并返回 ctx.Err()，此时不占用任何份额
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return fmt.Errorf("%w：%d > %d", ErrTooLarge, n, s.size)
+	}
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// 取消的同时已经获取成功，把份额还回去
+			s.cur -= n
+			s.notify()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// 队头放弃后，后面的小请求可能已经可以满足
+			if isFront && s.size > s.cur {
+				s.notify()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 不等待地获取 n 份额，成功返回 true
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release 归还 n 份额。归还超过已获取的份额会 panic
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("syncx: 信号量归还的份额超过已获取的份额")
+	}
+	s.notify()
+}
+
+// notify 按顺序唤醒能被满足的等待者，遇到不能满足的队头就停止（调用方持有 s.mu）
+func (s *Weighted) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
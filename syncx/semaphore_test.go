@@ -0,0 +1,137 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWeighted(t *testing.T) {
+	s := NewWeighted(10)
+	ctx := context.Background()
+	if err := s.Acquire(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if s.TryAcquire(4) {
+		t.Fatal("超出容量时 TryAcquire 成功")
+	}
+	if !s.TryAcquire(3) {
+		t.Fatal("容量足够时 TryAcquire 失败")
+	}
+	if err := s.Acquire(ctx, 11); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(timeout, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+	s.Release(10)
+	if !s.TryAcquire(10) {
+		t.Error("取消的等待者占用了份额")
+	}
+}
+
+func TestWeightedFIFO(t *testing.T) {
+	s := NewWeighted(4)
+	ctx := context.Background()
+	s.Acquire(ctx, 4)
+
+	// 队头等待 3 份额时，后来的 1 份额请求不能插队
+	big := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 3)
+		close(big)
+	}()
+	waitFor(t, func() bool { return s.waiterCount() == 1 })
+	if s.TryAcquire(1) {
+		t.Fatal("小请求插到了等待中的大请求前面")
+	}
+
+	s.Release(2)
+	select {
+	case <-big:
+		t.Fatal("份额不足时大请求被满足")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.Release(2)
+	<-big
+}
+
+func TestWeightedCancelFront(t *testing.T) {
+	s := NewWeighted(4)
+	ctx := context.Background()
+	s.Acquire(ctx, 2)
+
+	// 队头的大请求放弃后，排在后面的小请求应被唤醒
+	bigCtx, cancel := context.WithCancel(ctx)
+	bigDone := make(chan error)
+	go func() { bigDone <- s.Acquire(bigCtx, 4) }()
+	waitFor(t, func() bool { return s.waiterCount() == 1 })
+
+	small := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 1)
+		close(small)
+	}()
+	waitFor(t, func() bool { return s.waiterCount() == 2 })
+
+	cancel()
+	if err := <-bigDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want Canceled", err)
+	}
+	<-small
+}
+
+func TestWeightedStress(t *testing.T) {
+	const size = 10
+	s := NewWeighted(size)
+	var inUse atomic.Int64
+	var wg sync.WaitGroup
+	for g := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				n := int64((g+i)%size + 1)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%3)*time.Millisecond)
+				if err := s.Acquire(ctx, n); err != nil {
+					cancel()
+					continue
+				}
+				cancel()
+				if cur := inUse.Add(n); cur > size {
+					t.Errorf("占用 %d 份额，超过容量 %d", cur, size)
+				}
+				inUse.Add(-n)
+				s.Release(n)
+			}
+		}()
+	}
+	wg.Wait()
+	if !s.TryAcquire(size) {
+		t.Error("压力测试后份额没有全部归还")
+	}
+}
+
+func (s *Weighted) waiterCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
+
+// waitFor 轮询直到 cond 成立，最多等 1 秒
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待条件超时")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,122 @@
+package syncx
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// 自旋锁：锁笔记里"自旋锁 - 高性能场景"的实现。
+//   - SpinLock：test-and-test-and-set（先用普通读等到锁看起来空闲，再 CAS，减少缓存行争抢），
+//     失败后指数退避，退避到上限后每次重试前 runtime.Gosched() 让出处理器。从不挂起协程
+//   - AdaptiveMutex：先自旋有限次数，仍拿不到锁再挂起（park）在条件变量上，由 Unlock 唤醒
+// 自旋只在临界区极短（如 five_mu 的 count++）且处理器多于一个时才有意义：
+// 单处理器上持有者不运行就不可能释放锁，自旋纯属浪费，所以 AdaptiveMutex 在 GOMAXPROCS 为 1 时直接挂起。
+// 两者都不保证公平，被唤醒的等待者可能被新来的协程抢先；需要公平性请用 sync.Mutex（有饥饿模式）。
+
+const (
+	maxBackoff   = 64  // SpinLock 单次退避的最大空转次数
+	adaptiveSpin = 100 // AdaptiveMutex 挂起前的最大自旋次数
+)
+
+// spinSink 防止退避的空转循环被编译器优化掉
+var spinSink atomic.Int32
+
+// spin 空转 n 次
+func spin(n int) {
+	for i := 0; i < n; i++ {
+		spinSink.Load()
+	}
+}
+
+// SpinLock 带指数退避的 TTAS 自旋锁，零值可用
+type SpinLock struct {
+	state atomic.Int32 // 0 空闲，1 已锁定
+}
+
+// Lock 加锁
+func (l *SpinLock) Lock() {
+	backoff := 1
+	for {
+		// test：只读等待，不产生写操作
+		if l.state.Load() == 0 && l.state.CompareAndSwap(0, 1) { // test-and-set
+			return
+		}
+		if backoff < maxBackoff {
+			spin(backoff)
+			backoff <<= 1
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
+
+// TryLock 尝试加锁
+func (l *SpinLock) TryLock() bool {
+	return l.state.Load() == 0 && l.state.CompareAndSwap(0, 1)
+}
+
+// Unlock 解锁。解锁未加锁的 SpinLock 会 panic
+func (l *SpinLock) Unlock() {
+	if l.state.Swap(0) == 0 {
+		panic("syncx: unlock of unlocked SpinLock")
+	}
+}
+
+// AdaptiveMutex 先自旋后挂起的互斥锁，零值可用
+type AdaptiveMutex struct {
+	state   atomic.Int32 // 0 空闲，1 已锁定
+	waiters atomic.Int32 // 挂起中（或正准备挂起）的协程数
+
+	once sync.Once
+	mu   sync.Mutex // 只保护挂起/唤醒
+	cond *sync.Cond
+}
+
+// Lock 加锁
+func (m *AdaptiveMutex) Lock() {
+	if m.state.CompareAndSwap(0, 1) {
+		return
+	}
+	if runtime.GOMAXPROCS(0) > 1 {
+		backoff := 1
+		for i := 0; i < adaptiveSpin; i++ {
+			if m.state.Load() == 0 && m.state.CompareAndSwap(0, 1) {
+				return
+			}
+			spin(backoff)
+			backoff = min(backoff<<1, maxBackoff)
+		}
+	}
+	m.park()
+}
+
+// park 挂起直到拿到锁。先登记为等待者再尝试 CAS：
+// Unlock 先释放锁再检查等待者，因此两者之中至少有一个能看到对方，不会丢失唤醒
+func (m *AdaptiveMutex) park() {
+	m.once.Do(func() { m.cond = sync.NewCond(&m.mu) })
+	m.mu.Lock()
+	m.waiters.Add(1)
+	for !m.state.CompareAndSwap(0, 1) {
+		m.cond.Wait()
+	}
+	m.waiters.Add(-1)
+	m.mu.Unlock()
+}
+
+// TryLock 尝试加锁
+func (m *AdaptiveMutex) TryLock() bool {
+	return m.state.CompareAndSwap(0, 1)
+}
+
+// Unlock 解锁，有挂起的等待者时唤醒一个。解锁未加锁的 AdaptiveMutex 会 panic
+func (m *AdaptiveMutex) Unlock() {
+	if m.state.Swap(0) == 0 {
+		panic("syncx: unlock of unlocked AdaptiveMutex")
+	}
+	if m.waiters.Load() > 0 {
+		m.mu.Lock()
+		m.cond.Signal()
+		m.mu.Unlock()
+	}
+}
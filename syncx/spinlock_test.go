@@ -0,0 +1,93 @@
+package syncx
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// locks 参与测试和基准的锁
+var locks = []struct {
+	name string
+	new  func() sync.Locker
+}{
+	{"SpinLock", func() sync.Locker { return new(SpinLock) }},
+	{"AdaptiveMutex", func() sync.Locker { return new(AdaptiveMutex) }},
+	{"sync.Mutex", func() sync.Locker { return new(sync.Mutex) }},
+}
+
+func TestLocks(t *testing.T) {
+	for _, l := range locks {
+		t.Run(l.name, func(t *testing.T) {
+			for _, procs := range []int{1, 4} {
+				// GOMAXPROCS 为 1 时 AdaptiveMutex 直接挂起，大于 1 时先自旋，两条路径都要覆盖
+				prev := runtime.GOMAXPROCS(procs)
+				mu := l.new()
+				const goroutines, iterations = 16, 2000
+				count := 0
+				var wg sync.WaitGroup
+				for range goroutines {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for range iterations {
+							mu.Lock()
+							count++ // 没有互斥时 -race 会报告数据竞争，计数也会偏小
+							mu.Unlock()
+						}
+					}()
+				}
+				wg.Wait()
+				runtime.GOMAXPROCS(prev)
+				if count != goroutines*iterations {
+					t.Errorf("GOMAXPROCS=%d: count = %d, want %d", procs, count, goroutines*iterations)
+				}
+			}
+		})
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	for _, l := range []interface {
+		sync.Locker
+		TryLock() bool
+	}{new(SpinLock), new(AdaptiveMutex)} {
+		if !l.TryLock() {
+			t.Fatal("空闲时 TryLock 失败")
+		}
+		if l.TryLock() {
+			t.Fatal("已锁定时 TryLock 成功")
+		}
+		l.Unlock()
+		l.Lock()
+		l.Unlock()
+	}
+}
+
+// BenchmarkLocks 极短临界区（five_mu 的 count++），b.N 为加锁总次数，由各协程平分
+func BenchmarkLocks(b *testing.B) {
+	for _, g := range []int{1, 4, 16, 64} {
+		for _, l := range locks {
+			b.Run(fmt.Sprintf("goroutines=%d/%s", g, l.name), func(b *testing.B) {
+				mu := l.new()
+				count := 0
+				per := max(b.N/g, 1)
+				var wg sync.WaitGroup
+				b.ResetTimer()
+				for range g {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for range per {
+							mu.Lock()
+							count++
+							mu.Unlock()
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package two_goroutine
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 结果缓存：确定性的任务经常被重复执行，可以为任务指定缓存键，
+// 相同键的任务直接复用之前成功执行的结果（数据流任务的输出以 JSON 保存）。
+// 同一时刻提交的相同键任务只会执行一次，其余任务等待并复用其结果。
+//
+// 由于输出要经过 JSON 往返，只有能无损往返的输出类型才能缓存：
+// 接口、函数、通道、复数类型，以及含有未导出字段的结构体都会在 SetCacheKey 时被拒绝。
+// 实现了 json.Unmarshaler 的类型（如 time.Time）由其自身负责往返，不再检查。
+
+// ResultCache 任务结果缓存
+type ResultCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte) error
+}
+
+// cacheCall 一次正在进行的缓存键执行
+type cacheCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// SetResultCache 设置结果缓存，只有设置了 CacheKey 的任务才会使用
+func (ts *TaskScheduler) SetResultCache(cache ResultCache) {
+	ts.cache = cache
+}
+
+// SetCacheKey 为已添加的任务设置缓存键，数据流任务的输出类型必须能无损地经过 JSON 往返
+func (ts *TaskScheduler) SetCacheKey(name, key string) error {
+	for i := len(ts.tasks) - 1; i >= 0; i-- {
+		if ts.tasks[i].Name != name {
+			continue
+		}
+		if spec := ts.flows[name]; spec.out != nil {
+			if err := cacheable(spec.out, make(map[reflect.Type]bool)); err != nil {
+				return fmt.Errorf("任务 '%s' 的输出类型 %v 不能缓存：%w", name, spec.out, err)
+			}
+		}
+		ts.tasks[i].CacheKey = key
+		return nil
+	}
+	return fmt.Errorf("任务 '%s' 不存在", name)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// cacheable 检查类型的值能否无损地经过 JSON 往返，seen 用于处理递归类型
+func cacheable(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return fmt.Errorf("接口类型 %v 解码后无法还原具体类型", t)
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return fmt.Errorf("%v 类型无法编码为 JSON", t)
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return cacheable(t.Elem(), seen)
+	case reflect.Map:
+		if err := cacheable(t.Key(), seen); err != nil {
+			return err
+		}
+		return cacheable(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				return fmt.Errorf("%v 的未导出字段 %s 不会被编码", t, f.Name)
+			}
+			if f.Tag.Get("json") == "-" {
+				return fmt.Errorf("%v 的字段 %s 被 json:\"-\" 忽略", t, f.Name)
+			}
+			if err := cacheable(f.Type, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runCached 先查缓存，未命中时执行任务并写入缓存。
+// 相同键已有任务在执行时，等待它完成后复用结果；它失败了则自己再执行一次。
+// 查缓存（可能读磁盘）不持有 cacheMu，不同键的任务互不阻塞。查缓存与登记 inflight 之间
+// 相同键的执行恰好完成时，本任务会再执行一次，结果仍然正确。
+func (ts *TaskScheduler) runCached(t Task) {
+	key := t.CacheKey
+
+	if value, ok := ts.cache.Get(key); ok && ts.restoreOrLog(t, value) {
+		ts.recordHit(t)
+		return
+	}
+
+	ts.cacheMu.Lock()
+	if call, ok := ts.inflight[key]; ok {
+		ts.cacheMu.Unlock()
+		<-call.done
+		if call.err == nil && ts.restoreOrLog(t, call.value) {
+			ts.recordHit(t)
+			return
+		}
+		ts.execute(t)
+		return
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	if ts.inflight == nil {
+		ts.inflight = make(map[string]*cacheCall)
+	}
+	ts.inflight[key] = call
+	ts.cacheMu.Unlock()
+
+	call.err = ts.execute(t)
+	if call.err == nil {
+		if call.value, call.err = ts.snapshot(t); call.err != nil {
+			ts.logf("✗ 任务 '%s' 输出编码失败，不写入缓存：%v\n", t.Name, call.err)
+		}
+	}
+	if call.err == nil {
+		if err := ts.cache.Set(key, call.value); err != nil {
+			ts.logf("✗ 任务 '%s' 写入缓存失败：%v\n", t.Name, err)
+		}
+	}
+
+	// 先写缓存再移出 inflight，保证之后到达的任务一定能查到缓存
+	ts.cacheMu.Lock()
+	delete(ts.inflight, key)
+	ts.cacheMu.Unlock()
+	close(call.done)
+}
+
+// recordHit 记录一次缓存命中，耗时为 0
+func (ts *TaskScheduler) recordHit(t Task) {
+	ts.record(TaskResult{Name: t.Name, CacheHit: true})
+	ts.emit(Event{Kind: EventSucceeded, Name: t.Name, CacheHit: true})
+	ts.logf("✓ 任务 '%s' 命中缓存\n", t.Name)
+}
+
+// snapshot 把任务的输出编码为缓存值，没有输出的任务缓存为 null
+func (ts *TaskScheduler) snapshot(t Task) ([]byte, error) {
+	if spec, ok := ts.flows[t.Name]; !ok || spec.out == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ts.output(t.Name).Interface())
+}
+
+// restoreOrLog 调用 restore，解码失败时输出日志并返回 false，调用方随后重新执行任务
+func (ts *TaskScheduler) restoreOrLog(t Task, value []byte) bool {
+	if err := ts.restore(t, value); err != nil {
+		ts.logf("✗ 任务 '%s' 缓存值解码失败，重新执行：%v\n", t.Name, err)
+		return false
+	}
+	return true
+}
+
+// restore 把缓存值解码为任务的输出并发布给下游
+func (ts *TaskScheduler) restore(t Task, value []byte) error {
+	spec, ok := ts.flows[t.Name]
+	if !ok || spec.out == nil {
+		return nil
+	}
+	ptr := reflect.New(spec.out)
+	if err := json.Unmarshal(value, ptr.Interface()); err != nil {
+		return err
+	}
+	ts.publish(t.Name, ptr.Elem())
+	return nil
+}
+
+// MemoryResultCache 内存结果缓存，超过容量时淘汰最久未使用的条目
+type MemoryResultCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration // 条目有效期，<= 0 表示永不过期
+	maxEntries int           // 最大条目数，<= 0 表示不限制
+	order      *list.List    // 按最近使用排序，队头最新
+	entries    map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryResultCache 创建内存结果缓存
+func NewMemoryResultCache(ttl time.Duration, maxEntries int) *MemoryResultCache {
+	return &MemoryResultCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get 读取未过期的缓存值
+func (c *MemoryResultCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set 写入缓存值
+func (c *MemoryResultCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: value}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// DiskResultCache 磁盘结果缓存，每个键一个文件，文件修改时间即写入时间
+type DiskResultCache struct {
+	mu       sync.Mutex
+	dir      string
+	ttl      time.Duration // 条目有效期，<= 0 表示永不过期
+	maxBytes int64         // 缓存目录总大小上限，<= 0 表示不限制，超出时删除最旧的文件
+}
+
+// NewDiskResultCache 创建磁盘结果缓存，目录不存在时会自动创建
+func NewDiskResultCache(dir string, ttl time.Duration, maxBytes int64) (*DiskResultCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskResultCache{dir: dir, ttl: ttl, maxBytes: maxBytes}, nil
+}
+
+// Get 读取未过期的缓存值
+func (c *DiskResultCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set 写入缓存值，并在超出大小上限时淘汰最旧的文件。超过大小上限的值不写入，返回错误
+func (c *DiskResultCache) Set(key string, value []byte) error {
+	if c.maxBytes > 0 && int64(len(value)) > c.maxBytes {
+		return fmt.Errorf("缓存值 %d 字节，超过大小上限 %d 字节", len(value), c.maxBytes)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, value, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return c.evict(path)
+}
+
+// evict 删除最旧的文件，直到总大小不超过 maxBytes。keep 是刚写入的文件，不会被删除
+func (c *DiskResultCache) evict(keep string) error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.IsDir() || filepath.Ext(info.Name()) != ".cache" {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, info := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		// 修改时间精度有限，刚写入的文件可能排在同一时刻写入的旧文件之前
+		if filepath.Join(c.dir, info.Name()) == keep {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil {
+			return err
+		}
+		total -= info.Size()
+	}
+	return nil
+}
+
+// path 键可能包含任意字符，用其哈希作为文件名
+func (c *DiskResultCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".cache")
+}
@@ -0,0 +1,197 @@
+package two_goroutine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryResultCacheTTL(t *testing.T) {
+	c := NewMemoryResultCache(20*time.Millisecond, 0)
+	c.Set("k", []byte("v"))
+	if v, ok := c.Get("k"); !ok || string(v) != "v" {
+		t.Fatalf("Get() = %q, %v, want v", v, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("k"); ok {
+		t.Error("expired entry still returned")
+	}
+}
+
+func TestMemoryResultCacheMaxEntries(t *testing.T) {
+	c := NewMemoryResultCache(0, 2)
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+	c.Get("a") // a 最近使用过，淘汰 b
+	c.Set("c", []byte("3"))
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) ok = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestDiskResultCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskResultCache(dir, time.Hour, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 用修改时间区分新旧，避免依赖文件系统的时间精度
+	old := time.Now().Add(-time.Minute)
+	for i, key := range []string{"a", "b"} {
+		if err := c.Set(key, []byte("1234")); err != nil {
+			t.Fatal(err)
+		}
+		at := old.Add(time.Duration(i) * time.Second)
+		os.Chtimes(c.path(key), at, at)
+	}
+	// 总大小 12 > 10，淘汰最旧的 a
+	if err := c.Set("c", []byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) ok = %v, want %v", key, ok, want)
+		}
+	}
+
+	// 过期的文件读取时删除
+	expired := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(c.path("b"), expired, expired)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expired entry still returned")
+	}
+	if _, err := os.Stat(c.path("b")); !os.IsNotExist(err) {
+		t.Errorf("expired file not removed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 {
+		t.Errorf("files = %v, want only c", files)
+	}
+
+	// 超过大小上限的值不写入，也不淘汰已有的文件
+	if err := c.Set("大", []byte("12345678901")); err == nil {
+		t.Error("Set of a value larger than maxBytes: want error")
+	}
+	if _, ok := c.Get("大"); ok {
+		t.Error("oversized value was stored")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Error("oversized Set evicted c")
+	}
+
+	// 恰好等于上限的值淘汰其他所有文件，自己保留
+	if err := c.Set("d", []byte("1234567890")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("d"); !ok {
+		t.Error("Set evicted the value it just wrote")
+	}
+	if _, ok := c.Get("c"); ok {
+		t.Error("c not evicted")
+	}
+}
+
+func TestCacheCollapsesConcurrentRuns(t *testing.T) {
+	ts := newTestScheduler()
+	ts.SetResultCache(NewMemoryResultCache(0, 0))
+
+	var calls atomic.Int32
+	for _, name := range []string{"a", "b", "c"} {
+		if err := ts.AddFlow(name, func() (float64, error) {
+			calls.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			return 7.1, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ts.SetCacheKey(name, "rate"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts.Execute()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+	hits := 0
+	for _, r := range ts.GetResults() {
+		if r.CacheHit {
+			hits++
+		}
+		if v, ok := ts.Output(r.Name); !ok || v != 7.1 {
+			t.Errorf("Output(%s) = %v, %v, want 7.1", r.Name, v, ok)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("cache hits = %d, want 2", hits)
+	}
+
+	// 再次执行全部命中
+	ts.Execute()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls after second Execute = %d, want 1", n)
+	}
+}
+
+func TestCacheDecodeFailure(t *testing.T) {
+	cache := NewMemoryResultCache(0, 0)
+	cache.Set("k", []byte(`"不是数字"`))
+
+	ts := newTestScheduler()
+	var log strings.Builder
+	ts.SetOutput(&log)
+	ts.SetResultCache(cache)
+	ts.AddFlow("a", func() (int, error) { return 42, nil })
+	ts.SetCacheKey("a", "k")
+	ts.Execute()
+
+	if r := ts.GetResults()[0]; r.CacheHit || r.Error != nil {
+		t.Errorf("result = %+v, want executed", r)
+	}
+	if v, _ := ts.Output("a"); v != 42 {
+		t.Errorf("Output = %v, want 42", v)
+	}
+	if !strings.Contains(log.String(), "解码失败") {
+		t.Errorf("decode failure not logged: %s", log.String())
+	}
+}
+
+func TestSetCacheKeyRejectsLossyTypes(t *testing.T) {
+	type private struct{ n int }
+	type public struct {
+		N    int
+		When time.Time
+	}
+	tests := []struct {
+		name string
+		fn   any
+		ok   bool
+	}{
+		{"基本类型", func() (map[string][]int, error) { return nil, nil }, true},
+		{"导出字段", func() (*public, error) { return nil, nil }, true},
+		{"无输出", func() error { return nil }, true},
+		{"接口", func() (any, error) { return nil, nil }, false},
+		{"接口切片", func() ([]error, error) { return nil, nil }, false},
+		{"未导出字段", func() (private, error) { return private{}, nil }, false},
+		{"函数", func() (func(), error) { return nil, nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestScheduler()
+			if err := ts.AddFlow(tt.name, tt.fn); err != nil {
+				t.Fatal(err)
+			}
+			if err := ts.SetCacheKey(tt.name, "k"); (err == nil) != tt.ok {
+				t.Errorf("SetCacheKey() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package two_goroutine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dashboard 实时进度面板：订阅调度事件，定时刷新排队、运行、成功、失败、跳过的任务数，
+// 耗时最长的运行中任务，以及吞吐量走势（sparkline）。
+// 输出是终端时用 ANSI 转义序列原地重绘；否则（重定向到文件、CI 日志）每次刷新输出一行进度。
+type Dashboard struct {
+	mu        sync.Mutex
+	out       io.Writer
+	tty       bool
+	interval  time.Duration
+	queued    int
+	running   map[string][]time.Time // 运行中任务的开始时间，同名任务（多个调度器或重放）可能同时运行多个
+	nrunning  int
+	succeeded int
+	failed    int
+	skipped   int
+	completed int       // 本次刷新周期内完成的任务数
+	since     time.Time // 本次刷新周期的开始时间
+	rate      float64   // 上一个刷新周期的吞吐量（每秒完成数）
+	history   []int     // 最近若干个刷新周期的完成数，用于绘制 sparkline
+	drawn     int       // 上次绘制的行数，重绘时光标需要上移的行数
+	stop      chan struct{}
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+const (
+	dashboardSlowest  = 5                      // 展示耗时最长的运行中任务数
+	dashboardHistory  = 30                     // sparkline 保留的刷新周期数
+	dashboardInterval = 200 * time.Millisecond // 默认刷新间隔
+)
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// NewDashboard 创建进度面板，interval 为刷新间隔，<= 0 时使用默认值 200ms
+func NewDashboard(out io.Writer, interval time.Duration) *Dashboard {
+	if interval <= 0 {
+		interval = dashboardInterval
+	}
+	return &Dashboard{
+		out:      out,
+		tty:      isTerminal(out),
+		interval: interval,
+		running:  make(map[string][]time.Time),
+		since:    time.Now(),
+	}
+}
+
+// Attach 订阅调度器的事件，需在 Execute 之前调用
+func (d *Dashboard) Attach(ts *TaskScheduler) {
+	ts.Subscribe(d.Handle)
+}
+
+// Handle 处理一个调度事件
+func (d *Dashboard) Handle(e Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch e.Kind {
+	case EventQueued:
+		d.queued++
+	case EventStarted:
+		d.queued--
+		d.running[e.Name] = append(d.running[e.Name], e.Time)
+		d.nrunning++
+	case EventSucceeded, EventFailed, EventSkipped:
+		// 缓存命中和跳过的任务没有开始事件，直接从排队中移除；
+		// 同名任务无法区分是哪一个结束了，移除最早开始的那个
+		if starts := d.running[e.Name]; len(starts) > 0 && !e.CacheHit && e.Kind != EventSkipped {
+			if len(starts) == 1 {
+				delete(d.running, e.Name)
+			} else {
+				d.running[e.Name] = starts[1:]
+			}
+			d.nrunning--
+		} else {
+			d.queued--
+		}
+		switch e.Kind {
+		case EventSucceeded:
+			d.succeeded++
+		case EventFailed:
+			d.failed++
+		case EventSkipped:
+			d.skipped++
+		}
+		d.completed++
+	}
+}
+
+// Start 开始定时刷新，重复调用无效
+func (d *Dashboard) Start() {
+	d.startOnce.Do(d.start)
+}
+
+func (d *Dashboard) start() {
+	d.mu.Lock()
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	d.since = time.Now()
+	d.mu.Unlock()
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.tick()
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止刷新，并绘制最终状态。没有 Start 时只绘制最终状态，重复调用无效
+func (d *Dashboard) Stop() {
+	d.stopOnce.Do(func() {
+		d.startOnce.Do(func() {}) // 之后的 Start 不再启动刷新
+		d.mu.Lock()
+		stop, done := d.stop, d.done
+		d.mu.Unlock()
+		if stop != nil {
+			close(stop)
+			<-done
+		}
+		d.tick()
+	})
+}
+
+// tick 结束一个刷新周期并绘制
+func (d *Dashboard) tick() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 最后一个周期通常不满一个刷新间隔，按实际经过的时间计算吞吐量
+	now := time.Now()
+	if elapsed := now.Sub(d.since); elapsed > 0 {
+		d.rate = float64(d.completed) / elapsed.Seconds()
+	}
+	d.since = now
+	d.history = append(d.history, d.completed)
+	if len(d.history) > dashboardHistory {
+		d.history = d.history[len(d.history)-dashboardHistory:]
+	}
+	d.completed = 0
+
+	if d.tty {
+		d.draw()
+	} else {
+		fmt.Fprintf(d.out, "[%s] %s\n", time.Now().Format("15:04:05"), d.status())
+	}
+}
+
+// status 一行状态：各状态任务数与当前吞吐量
+func (d *Dashboard) status() string {
+	return fmt.Sprintf("排队 %d | 运行 %d | 成功 %d | 失败 %d | 跳过 %d | 吞吐 %.1f/s",
+		d.queued, d.nrunning, d.succeeded, d.failed, d.skipped, d.rate)
+}
+
+// draw 回到上次绘制的起点，清屏后重新绘制
+func (d *Dashboard) draw() {
+	lines := []string{
+		d.status(),
+		"走势 " + sparkline(d.history),
+	}
+
+	type runningTask struct {
+		name  string
+		start time.Time
+	}
+	tasks := make([]runningTask, 0, d.nrunning)
+	for name, starts := range d.running {
+		for _, start := range starts {
+			tasks = append(tasks, runningTask{name, start})
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].start.Before(tasks[j].start)
+	})
+	if len(tasks) > dashboardSlowest {
+		tasks = tasks[:dashboardSlowest]
+	}
+	if len(tasks) > 0 {
+		lines = append(lines, "运行最久的任务：")
+	}
+	for _, task := range tasks {
+		elapsed := time.Since(task.start).Truncate(time.Millisecond)
+		lines = append(lines, fmt.Sprintf("  %-15s %v", task.name, elapsed))
+	}
+
+	var b strings.Builder
+	if d.drawn > 0 {
+		fmt.Fprintf(&b, "\033[%dF", d.drawn) // 光标上移到上次绘制的第一行行首
+	}
+	b.WriteString("\033[J") // 清除光标之后的内容
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	io.WriteString(d.out, b.String())
+	d.drawn = len(lines)
+}
+
+// sparkline 按最大值把每个数映射到 8 级方块字符
+func sparkline(values []int) string {
+	top := 0
+	for _, v := range values {
+		top = max(top, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if top > 0 {
+			i = v * (len(sparkBars) - 1) / top
+		}
+		b.WriteRune(sparkBars[i])
+	}
+	return b.String()
+}
+
+// isTerminal 判断输出是否为终端（字符设备）
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
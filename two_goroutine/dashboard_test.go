@@ -0,0 +1,68 @@
+package two_goroutine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardPlainOutput(t *testing.T) {
+	var out bytes.Buffer
+	d := NewDashboard(&out, time.Hour) // 只在 Stop 时绘制一次
+
+	// 两个调度器的任务同名，计数不能互相干扰
+	schedulers := []*TaskScheduler{newTestScheduler(), newTestScheduler()}
+	for _, ts := range schedulers {
+		ts.AddTask("成功", func() error { return nil })
+		ts.AddTask("失败", func() error { return errors.New("失败") })
+		ts.AddTaskAfter("跳过", func() error { return nil }, "失败")
+		d.Attach(ts)
+	}
+
+	d.Start()
+	for _, ts := range schedulers {
+		ts.Execute()
+	}
+	d.Stop()
+
+	got := out.String()
+	if strings.Contains(got, "\033[") {
+		t.Errorf("plain output contains ANSI escapes: %q", got)
+	}
+	if lines := strings.Count(got, "\n"); lines != 1 {
+		t.Errorf("got %d lines, want 1: %q", lines, got)
+	}
+	want := "排队 0 | 运行 0 | 成功 2 | 失败 2 | 跳过 2 | 吞吐 "
+	if !strings.Contains(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	// 最后一个周期远不到 1 小时，吞吐量按实际经过的时间计算
+	var rate float64
+	if _, err := fmt.Sscanf(got[strings.Index(got, "吞吐 ")+len("吞吐 "):], "%f/s", &rate); err != nil || rate < 1 {
+		t.Errorf("throughput = %v (%v), want 6 tasks over the elapsed time, not an hour", rate, err)
+	}
+}
+
+func TestDashboardStopWithoutStart(t *testing.T) {
+	var out bytes.Buffer
+	d := NewDashboard(&out, time.Hour)
+	d.Stop() // 没有 Start 也不能 panic
+	d.Stop()
+	d.Start() // Stop 之后不再启动
+	d.Stop()
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("got %d lines, want the final state drawn once: %q", lines, out.String())
+	}
+}
+
+func TestDashboardDefaultInterval(t *testing.T) {
+	d := NewDashboard(&bytes.Buffer{}, 0)
+	if d.interval != dashboardInterval {
+		t.Errorf("interval = %v, want %v", d.interval, dashboardInterval)
+	}
+	d.Start() // 非正的间隔会让 time.NewTicker panic
+	d.Stop()
+}
@@ -0,0 +1,278 @@
+package two_goroutine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// 死信队列：任务在重试用尽后仍失败，就把它连同错误历史存起来，
+// 等问题修复后再通过 Replay 重新提交给调度器。
+
+// DeadLetter 一条死信记录
+type DeadLetter struct {
+	Name     string    `json:"name"`
+	Retries  int       `json:"retries"`
+	Attempts int       `json:"attempts"`
+	Errors   []string  `json:"errors"` // 每次失败的错误信息（error 本身无法序列化，只保存文本）
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// NewDeadLetter 根据任务及其错误历史创建死信记录
+func NewDeadLetter(t Task, errs []error) DeadLetter {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return DeadLetter{
+		Name:     t.Name,
+		Retries:  t.Retries,
+		Attempts: len(errs),
+		Errors:   msgs,
+		FailedAt: time.Now(),
+	}
+}
+
+// DeadLetterStore 死信存储
+type DeadLetterStore interface {
+	Put(dl DeadLetter) error      // 写入一条死信
+	List() ([]DeadLetter, error)  // 按写入顺序列出所有死信
+	Remove(names ...string) error // 按任务名删除死信
+	Replace(dl DeadLetter) error  // 用 dl 替换同名的所有死信，没有同名死信时写入
+}
+
+// MemoryDeadLetterStore 内存死信存储
+type MemoryDeadLetterStore struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+// NewMemoryDeadLetterStore 创建内存死信存储
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{}
+}
+
+// Put 写入一条死信
+func (s *MemoryDeadLetterStore) Put(dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, dl)
+	return nil
+}
+
+// List 列出所有死信
+func (s *MemoryDeadLetterStore) List() ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DeadLetter(nil), s.letters...), nil
+}
+
+// Remove 按任务名删除死信
+func (s *MemoryDeadLetterStore) Remove(names ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = without(s.letters, names)
+	return nil
+}
+
+// Replace 用 dl 替换同名的所有死信
+func (s *MemoryDeadLetterStore) Replace(dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(without(s.letters, []string{dl.Name}), dl)
+	return nil
+}
+
+// FileDeadLetterStore JSON-lines 文件死信存储，每行一条死信
+type FileDeadLetterStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileDeadLetterStore 创建文件死信存储，文件不存在时会在第一次写入时创建
+func NewFileDeadLetterStore(path string) *FileDeadLetterStore {
+	return &FileDeadLetterStore{path: path}
+}
+
+// Put 追加一行死信
+func (s *FileDeadLetterStore) Put(dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List 读取文件中的所有死信
+func (s *FileDeadLetterStore) List() ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Remove 按任务名删除死信（重写整个文件）
+func (s *FileDeadLetterStore) Remove(names ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters, err := s.read()
+	if err != nil {
+		return err
+	}
+	return s.write(without(letters, names))
+}
+
+// Replace 用 dl 替换同名的所有死信（重写整个文件）。替换是一次重命名，中途崩溃时旧死信仍在
+func (s *FileDeadLetterStore) Replace(dl DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letters, err := s.read()
+	if err != nil {
+		return err
+	}
+	return s.write(append(without(letters, []string{dl.Name}), dl))
+}
+
+// write 用 letters 覆盖文件：先写临时文件再重命名，避免写到一半时丢失数据
+func (s *FileDeadLetterStore) write(letters []DeadLetter) error {
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, dl := range letters {
+		if err = enc.Encode(dl); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileDeadLetterStore) read() ([]DeadLetter, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var dl DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &dl); err != nil {
+			return nil, fmt.Errorf("%s 第 %d 行：%w", s.path, line, err)
+		}
+		letters = append(letters, dl)
+	}
+	return letters, scanner.Err()
+}
+
+// retried 返回重放再次失败后的死信：累加尝试次数，追加本次的错误历史
+func (dl DeadLetter) retried(errs []error) DeadLetter {
+	next := NewDeadLetter(Task{Name: dl.Name, Retries: dl.Retries}, errs)
+	next.Attempts += dl.Attempts
+	next.Errors = append(append([]string(nil), dl.Errors...), next.Errors...)
+	return next
+}
+
+// without 返回去掉指定任务名后的死信列表
+func without(letters []DeadLetter, names []string) []DeadLetter {
+	drop := make(map[string]bool, len(names))
+	for _, name := range names {
+		drop[name] = true
+	}
+	kept := letters[:0:0]
+	for _, dl := range letters {
+		if !drop[dl.Name] {
+			kept = append(kept, dl)
+		}
+	}
+	return kept
+}
+
+// Replay 把选中的死信重新提交给调度器。
+// 任务函数无法持久化，需要通过 registry 按任务名找回；names 为空时重放全部死信。
+// 死信在重新提交时不会删除：任务执行成功后才从 store 中删除，再次失败则把本次的尝试次数和错误追加到死信中。
+// 为此 Replay 会把调度器的死信存储设置为 store。
+// 返回实际重新提交的任务名，调用方随后执行 ts.Execute()。
+func Replay(store DeadLetterStore, ts *TaskScheduler, registry []Task, names ...string) ([]string, error) {
+	letters, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	fns := make(map[string]func() error, len(registry))
+	for _, t := range registry {
+		fns[t.Name] = t.Fn
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	// 同一个任务可能多次进入死信，只重放一次
+	var selected []DeadLetter
+	seen := make(map[string]bool)
+	for _, dl := range letters {
+		if (len(names) > 0 && !wanted[dl.Name]) || seen[dl.Name] {
+			continue
+		}
+		if _, ok := fns[dl.Name]; !ok {
+			return nil, fmt.Errorf("死信 '%s' 在任务注册表中不存在", dl.Name)
+		}
+		if ts.hasTask(dl.Name) {
+			return nil, fmt.Errorf("任务 '%s' 已存在", dl.Name)
+		}
+		seen[dl.Name] = true
+		selected = append(selected, dl)
+	}
+	for _, name := range names {
+		if !seen[name] {
+			return nil, fmt.Errorf("死信 '%s' 不存在", name)
+		}
+	}
+
+	replayed := make([]string, 0, len(selected))
+	for _, dl := range selected {
+		replayed = append(replayed, dl.Name)
+	}
+	ts.SetDeadLetterStore(store)
+	for _, dl := range selected {
+		if err := ts.addTask(Task{Name: dl.Name, Fn: fns[dl.Name], Retries: dl.Retries, replayed: &dl}); err != nil {
+			return nil, err
+		}
+	}
+	return replayed, nil
+}
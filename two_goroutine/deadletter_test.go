@@ -0,0 +1,163 @@
+package two_goroutine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestScheduler 创建不输出日志的调度器
+func newTestScheduler() *TaskScheduler {
+	ts := NewTaskScheduler()
+	ts.SetOutput(io.Discard)
+	return ts
+}
+
+func TestRetryExhaustion(t *testing.T) {
+	store := NewMemoryDeadLetterStore()
+	ts := newTestScheduler()
+	ts.SetDeadLetterStore(store)
+
+	calls := 0
+	ts.AddTaskWithRetry("失败", 2, func() error {
+		calls++
+		return fmt.Errorf("第%d次失败", calls)
+	})
+	flaky := 0
+	ts.AddTaskWithRetry("重试后成功", 2, func() error {
+		if flaky++; flaky == 1 {
+			return errors.New("偶发失败")
+		}
+		return nil
+	})
+	ts.Execute()
+
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	letters, _ := store.List()
+	if len(letters) != 1 {
+		t.Fatalf("letters = %v, want 1", letters)
+	}
+	dl := letters[0]
+	if dl.Name != "失败" || dl.Retries != 2 || dl.Attempts != 3 {
+		t.Errorf("letter = %+v", dl)
+	}
+	if want := []string{"第1次失败", "第2次失败", "第3次失败"}; !reflect.DeepEqual(dl.Errors, want) {
+		t.Errorf("errors = %v, want %v", dl.Errors, want)
+	}
+}
+
+func TestFileDeadLetterStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletter.jsonl")
+	store := NewFileDeadLetterStore(path)
+
+	if letters, err := store.List(); err != nil || letters != nil {
+		t.Fatalf("List() on missing file = %v, %v", letters, err)
+	}
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []DeadLetter{
+		{Name: "a", Retries: 1, Attempts: 2, Errors: []string{"x", "y"}, FailedAt: at},
+		{Name: "b", Attempts: 1, Errors: []string{"z"}, FailedAt: at},
+		{Name: "a", Retries: 1, Attempts: 2, Errors: []string{"w"}, FailedAt: at},
+	}
+	for _, dl := range want {
+		if err := store.Put(dl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 重新打开文件，模拟进程重启
+	got, err := NewFileDeadLetterStore(path).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+
+	if err := store.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = store.List()
+	if !reflect.DeepEqual(got, want[1:2]) {
+		t.Errorf("after Remove = %+v, want %+v", got, want[1:2])
+	}
+
+	replaced := DeadLetter{Name: "b", Attempts: 3, Errors: []string{"z", "z", "z"}, FailedAt: at}
+	if err := store.Replace(replaced); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = store.List()
+	if !reflect.DeepEqual(got, []DeadLetter{replaced}) {
+		t.Errorf("after Replace = %+v, want %+v", got, replaced)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	store := NewFileDeadLetterStore(filepath.Join(t.TempDir(), "deadletter.jsonl"))
+	store.Put(DeadLetter{Name: "修好了", Retries: 1})
+	store.Put(DeadLetter{Name: "还是坏的", Retries: 1, Attempts: 2, Errors: []string{"旧错误", "旧错误"}})
+	store.Put(DeadLetter{Name: "修好了", Retries: 1})
+
+	fixedCalls, brokenCalls := 0, 0
+	registry := []Task{
+		{Name: "修好了", Fn: func() error { fixedCalls++; return nil }},
+		{Name: "还是坏的", Fn: func() error { brokenCalls++; return errors.New("仍然失败") }},
+	}
+
+	ts := newTestScheduler()
+	names, err := Replay(store, ts, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"修好了", "还是坏的"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	// 执行之前死信不能删除，否则重放中途崩溃会丢失
+	if letters, _ := store.List(); len(letters) != 3 {
+		t.Errorf("before Execute: %d letters, want 3", len(letters))
+	}
+
+	ts.Execute()
+	if fixedCalls != 1 || brokenCalls != 2 {
+		t.Errorf("calls = %d, %d, want 1, 2", fixedCalls, brokenCalls)
+	}
+	// 成功的死信删除；再次失败的不重复写入，而是追加本次的尝试次数和错误
+	letters, _ := store.List()
+	if len(letters) != 1 || letters[0].Name != "还是坏的" {
+		t.Fatalf("after Execute: %+v, want only 还是坏的", letters)
+	}
+	dl := letters[0]
+	if want := []string{"旧错误", "旧错误", "仍然失败", "仍然失败"}; dl.Attempts != 4 || !reflect.DeepEqual(dl.Errors, want) {
+		t.Errorf("updated letter = %+v, want 4 attempts and errors %v", dl, want)
+	}
+	if dl.FailedAt.IsZero() {
+		t.Error("updated letter has no FailedAt")
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	store := NewMemoryDeadLetterStore()
+	store.Put(DeadLetter{Name: "未注册"})
+
+	if _, err := Replay(store, newTestScheduler(), nil); err == nil {
+		t.Error("Replay with unknown task: want error")
+	}
+	if _, err := Replay(store, newTestScheduler(), nil, "不存在"); err == nil {
+		t.Error("Replay of missing letter: want error")
+	}
+
+	ts := newTestScheduler()
+	ts.AddTask("未注册", func() error { return nil })
+	registry := []Task{{Name: "未注册", Fn: func() error { return nil }}}
+	if _, err := Replay(store, ts, registry); err == nil {
+		t.Error("Replay of a task already in the scheduler: want error")
+	}
+}
@@ -0,0 +1,61 @@
+package two_goroutine
+
+import "time"
+
+// 调度事件：任务状态变化时通知订阅者，用于实时展示进度（见 dashboard.go）。
+
+// EventKind 事件类型
+type EventKind int
+
+const (
+	EventQueued    EventKind = iota // 任务进入队列，等待上游或调度
+	EventStarted                    // 任务开始执行
+	EventSucceeded                  // 任务执行成功（含缓存命中）
+	EventFailed                     // 任务重试用尽后仍失败
+	EventSkipped                    // 上游失败或所在 Saga 失败，任务被跳过
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventQueued:
+		return "排队"
+	case EventStarted:
+		return "开始"
+	case EventSucceeded:
+		return "成功"
+	case EventFailed:
+		return "失败"
+	case EventSkipped:
+		return "跳过"
+	}
+	return "未知"
+}
+
+// Event 一次任务状态变化
+type Event struct {
+	Kind     EventKind
+	Name     string
+	Time     time.Time
+	Duration time.Duration // 成功或失败时的执行耗时
+	Err      error         // 失败或跳过的原因
+	CacheHit bool          // 成功事件是否来自缓存
+}
+
+// Subscribe 订阅调度事件，需在 Execute 之前调用。
+// 事件在任务所在的协程中同步回调，fn 需要是并发安全的，并且应尽快返回。
+func (ts *TaskScheduler) Subscribe(fn func(Event)) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.handlers = append(ts.handlers, fn)
+}
+
+func (ts *TaskScheduler) emit(e Event) {
+	ts.mu.Lock()
+	handlers := ts.handlers
+	ts.mu.Unlock()
+
+	e.Time = time.Now()
+	for _, fn := range handlers {
+		fn(e)
+	}
+}
@@ -0,0 +1,141 @@
+package two_goroutine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// 任务依赖与数据流：任务可以声明上游依赖，等上游全部成功后再执行；
+// 数据流任务还会把上游的输出作为参数传入，并发布自己的输出给下游。
+// 参数类型在添加任务（构建任务图）时就用反射检查，而不是等到运行时才出错。
+
+// ErrUpstreamFailed 上游任务失败，下游任务被跳过
+var ErrUpstreamFailed = errors.New("上游任务失败")
+
+// ErrUnknownDep 任务依赖的任务不存在（或依赖自身），任务不会执行
+var ErrUnknownDep = errors.New("依赖的任务不存在")
+
+// skipped 报告任务是否因上游失败或所在 Saga 失败而没有执行
+func skipped(err error) bool {
+	return errors.Is(err, ErrUpstreamFailed) || errors.Is(err, ErrSagaAborted)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// flowSpec 数据流任务的签名信息
+type flowSpec struct {
+	out reflect.Type // 输出类型，nil 表示没有输出
+}
+
+// AddTaskAfter 添加依赖上游任务的普通任务，上游任务必须已经添加
+func (ts *TaskScheduler) AddTaskAfter(name string, fn func() error, deps ...string) error {
+	for _, dep := range deps {
+		if !ts.hasTask(dep) {
+			return fmt.Errorf("任务 '%s' 依赖的任务 '%s' 不存在", name, dep)
+		}
+	}
+	return ts.addTask(Task{Name: name, Fn: fn, Deps: deps})
+}
+
+// AddFlow 添加数据流任务。
+// fn 的签名必须是 func(T1, T2, ...) (Out, error) 或 func(T1, T2, ...) error，
+// 第 i 个参数接收 deps[i] 的输出，因此每个上游任务都必须是有输出的数据流任务，
+// 且其输出类型可以赋值给对应参数。上游任务必须先添加，任务图因此不会有环。
+func (ts *TaskScheduler) AddFlow(name string, fn any, deps ...string) error {
+	if ts.hasTask(name) {
+		return fmt.Errorf("任务 '%s' 已存在", name)
+	}
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return fmt.Errorf("任务 '%s'：fn 必须是函数，实际为 %v", name, ft)
+	}
+	if ft.IsVariadic() || ft.NumIn() != len(deps) {
+		return fmt.Errorf("任务 '%s'：函数有 %d 个参数，但声明了 %d 个上游任务", name, ft.NumIn(), len(deps))
+	}
+
+	var spec flowSpec
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) == errorType:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+		spec.out = ft.Out(0)
+	default:
+		return fmt.Errorf("任务 '%s'：函数必须返回 error 或 (输出, error)，实际为 %v", name, ft)
+	}
+
+	for i, dep := range deps {
+		up, ok := ts.flows[dep]
+		if !ok {
+			return fmt.Errorf("任务 '%s' 依赖的数据流任务 '%s' 不存在", name, dep)
+		}
+		if up.out == nil {
+			return fmt.Errorf("任务 '%s'：上游任务 '%s' 没有输出", name, dep)
+		}
+		if !up.out.AssignableTo(ft.In(i)) {
+			return fmt.Errorf("任务 '%s'：第 %d 个参数类型为 %v，上游任务 '%s' 的输出类型为 %v", name, i+1, ft.In(i), dep, up.out)
+		}
+	}
+
+	if ts.flows == nil {
+		ts.flows = make(map[string]flowSpec)
+	}
+	ts.flows[name] = spec
+
+	return ts.addTask(Task{Name: name, Deps: deps, Fn: func() error {
+		args := make([]reflect.Value, len(deps))
+		for i, dep := range deps {
+			args[i] = ts.output(dep)
+		}
+		res := fv.Call(args)
+		if err, _ := res[len(res)-1].Interface().(error); err != nil {
+			return err
+		}
+		if spec.out != nil {
+			ts.publish(name, res[0])
+		}
+		return nil
+	}})
+}
+
+// Output 返回数据流任务在最近一次执行中发布的输出
+func (ts *TaskScheduler) Output(name string) (any, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	v, ok := ts.outputs[name]
+	if !ok {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+func (ts *TaskScheduler) output(name string) reflect.Value {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.outputs[name]
+}
+
+func (ts *TaskScheduler) publish(name string, v reflect.Value) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.outputs[name] = v
+}
+
+// checkDeps 检查任务的每个依赖都是调度器中的其他任务
+func (ts *TaskScheduler) checkDeps(t Task) error {
+	for _, dep := range t.Deps {
+		if dep == t.Name || !ts.hasTask(dep) {
+			return fmt.Errorf("%w：%s", ErrUnknownDep, dep)
+		}
+	}
+	return nil
+}
+
+func (ts *TaskScheduler) hasTask(name string) bool {
+	for _, t := range ts.tasks {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
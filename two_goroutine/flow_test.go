@@ -0,0 +1,170 @@
+package two_goroutine
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddFlowTypeCheck(t *testing.T) {
+	ts := newTestScheduler()
+	if err := ts.AddFlow("数字", func() (int, error) { return 1, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.AddTask("普通任务", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		fn   any
+		deps []string
+		want string
+	}{
+		{"类型不匹配", func(s string) error { return nil }, []string{"数字"}, "输出类型为 int"},
+		{"参数个数不符", func(a, b int) error { return nil }, []string{"数字"}, "有 2 个参数"},
+		{"不是函数", 42, nil, "必须是函数"},
+		{"返回值不对", func(n int) int { return n }, []string{"数字"}, "必须返回 error"},
+		{"上游不存在", func(n int) error { return nil }, []string{"不存在"}, "不存在"},
+		{"上游不是数据流", func(n int) error { return nil }, []string{"普通任务"}, "不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ts.AddFlow(tt.name, tt.fn, tt.deps...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("AddFlow() = %v, want error containing %q", err, tt.want)
+			}
+			if ts.hasTask(tt.name) {
+				t.Errorf("rejected task %q was added", tt.name)
+			}
+		})
+	}
+}
+
+func TestDuplicateTaskName(t *testing.T) {
+	ts := newTestScheduler()
+	if err := ts.AddFlow("a", func() (int, error) { return 1, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ts.AddTask("a", func() error { return nil }); err == nil {
+		t.Error("AddTask with duplicate name: want error")
+	}
+	if err := ts.AddTaskWithRetry("a", 1, func() error { return nil }); err == nil {
+		t.Error("AddTaskWithRetry with duplicate name: want error")
+	}
+	if err := ts.AddTaskAfter("a", func() error { return nil }); err == nil {
+		t.Error("AddTaskAfter with duplicate name: want error")
+	}
+	// 重名的数据流任务不能覆盖原有的输出类型
+	if err := ts.AddFlow("a", func() (string, error) { return "", nil }); err == nil {
+		t.Error("AddFlow with duplicate name: want error")
+	}
+	if err := ts.AddFlow("b", func(n int) error { return nil }, "a"); err != nil {
+		t.Errorf("AddFlow after rejected duplicate: %v", err)
+	}
+}
+
+func TestFlowSkipsAfterUpstreamFailure(t *testing.T) {
+	ts := newTestScheduler()
+	ran := make(map[string]bool)
+	ts.AddFlow("抽取", func() (int, error) { return 0, errors.New("抽取失败") })
+	ts.AddFlow("转换", func(n int) (int, error) { ran["转换"] = true; return n, nil }, "抽取")
+	ts.AddFlow("加载", func(n int) error { ran["加载"] = true; return nil }, "转换")
+	ts.AddFlow("独立", func() (int, error) { return 7, nil })
+	ts.Execute()
+
+	if len(ran) != 0 {
+		t.Errorf("downstream tasks ran: %v", ran)
+	}
+	results := make(map[string]TaskResult)
+	for _, r := range ts.GetResults() {
+		results[r.Name] = r
+	}
+	for _, name := range []string{"转换", "加载"} {
+		if r := results[name]; !errors.Is(r.Error, ErrUpstreamFailed) || r.Attempts != 0 {
+			t.Errorf("%s: error = %v, attempts = %d, want skipped", name, r.Error, r.Attempts)
+		}
+	}
+	if r := results["独立"]; r.Error != nil {
+		t.Errorf("独立: %v", r.Error)
+	}
+	if v, ok := ts.Output("独立"); !ok || v != 7 {
+		t.Errorf("Output(独立) = %v, %v, want 7", v, ok)
+	}
+}
+
+func TestFlowPassesOutputs(t *testing.T) {
+	ts := newTestScheduler()
+	var got []string
+	steps := []error{
+		ts.AddFlow("订单", func() ([]int, error) { return []int{120, 80}, nil }),
+		ts.AddFlow("退款", func() (int, error) { return 30, nil }),
+		ts.AddFlow("净额", func(orders []int, refund int) (int, error) {
+			total := -refund
+			for _, v := range orders {
+				total += v
+			}
+			return total, nil
+		}, "订单", "退款"),
+		ts.AddFlow("报表", func(net int) error {
+			got = append(got, strings.Repeat("*", net/10))
+			return nil
+		}, "净额"),
+	}
+	if err := errors.Join(steps...); err != nil {
+		t.Fatal(err)
+	}
+	ts.Execute()
+
+	// 上游的输出按 deps 的顺序作为下游的参数
+	if v, ok := ts.Output("净额"); !ok || v != 170 {
+		t.Errorf("Output(净额) = %v, %v, want 170", v, ok)
+	}
+	if want := []string{"*****************"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("报表 received %v, want %v", got, want)
+	}
+	if _, ok := ts.Output("报表"); ok {
+		t.Error("Output(报表) present for a flow without output")
+	}
+}
+
+func TestUnknownDep(t *testing.T) {
+	ts := newTestScheduler()
+	ts.AddTask("a", func() error { return nil })
+	ran := false
+	// 直接设置 Deps 绕过 AddTaskAfter 的检查
+	ts.tasks = append(ts.tasks,
+		Task{Name: "b", Deps: []string{"不存在"}, Fn: func() error { ran = true; return nil }},
+		Task{Name: "c", Deps: []string{"c"}, Fn: func() error { ran = true; return nil }},
+	)
+	ts.tasks[0].Deps = []string{"b"}
+
+	finished := make(chan struct{})
+	go func() {
+		ts.Execute()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Execute blocked on an unknown dependency")
+	}
+	if ran {
+		t.Error("task with an unknown dependency ran")
+	}
+	results := make(map[string]TaskResult)
+	for _, r := range ts.GetResults() {
+		results[r.Name] = r
+	}
+	for _, name := range []string{"b", "c"} {
+		if err := results[name].Error; !errors.Is(err, ErrUnknownDep) {
+			t.Errorf("%s: error = %v, want ErrUnknownDep", name, err)
+		}
+	}
+	if err := results["a"].Error; !errors.Is(err, ErrUpstreamFailed) {
+		t.Errorf("a: error = %v, want ErrUpstreamFailed", err)
+	}
+}
@@ -0,0 +1,125 @@
+package two_goroutine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Saga：多步骤操作要么全部成功，要么全部撤销。
+// 通过依赖关系连在一起、且至少有一个步骤带补偿操作的任务构成一个 Saga，
+// 同一个调度器中可以有多个互不相关的 Saga。某个步骤失败时，同一 Saga 中尚未开始的步骤被取消，
+// 已经成功的步骤按完成顺序的逆序执行补偿，补偿结果记录在对应的 TaskResult 中；其他 Saga 不受影响。
+
+// ErrSagaAborted 所在 Saga 中已有步骤失败，尚未开始的步骤被取消
+var ErrSagaAborted = errors.New("Saga 已失败")
+
+// CompensationResult 补偿操作的执行结果
+type CompensationResult struct {
+	Duration time.Duration
+	Error    error
+}
+
+// SetCompensation 为已添加的任务设置补偿操作
+func (ts *TaskScheduler) SetCompensation(name string, fn func() error) error {
+	for i := len(ts.tasks) - 1; i >= 0; i-- {
+		if ts.tasks[i].Name == name {
+			ts.tasks[i].Compensate = fn
+			return nil
+		}
+	}
+	return fmt.Errorf("任务 '%s' 不存在", name)
+}
+
+// AddStep 添加带补偿操作的任务，deps 为依赖的上游任务
+func (ts *TaskScheduler) AddStep(name string, fn, compensate func() error, deps ...string) error {
+	if err := ts.AddTaskAfter(name, fn, deps...); err != nil {
+		return err
+	}
+	ts.tasks[len(ts.tasks)-1].Compensate = compensate
+	return nil
+}
+
+// sagas 按依赖关系把任务分组（并查集），返回属于 Saga 的任务 → 所在 Saga 的根任务。
+// 不含补偿操作的分组不是 Saga，不会出现在结果中。
+func (ts *TaskScheduler) sagas() map[string]string {
+	parent := make(map[string]string, len(ts.tasks))
+	var find func(string) string
+	find = func(name string) string {
+		p, ok := parent[name]
+		if !ok || p == name {
+			return name
+		}
+		root := find(p)
+		parent[name] = root
+		return root
+	}
+	for _, t := range ts.tasks {
+		parent[t.Name] = find(t.Name)
+		for _, dep := range t.Deps {
+			parent[find(dep)] = find(t.Name)
+		}
+	}
+
+	hasCompensation := make(map[string]bool)
+	for _, t := range ts.tasks {
+		if t.Compensate != nil {
+			hasCompensation[find(t.Name)] = true
+		}
+	}
+	sagaOf := make(map[string]string)
+	for _, t := range ts.tasks {
+		if root := find(t.Name); hasCompensation[root] {
+			sagaOf[t.Name] = root
+		}
+	}
+	return sagaOf
+}
+
+// abortedBy 返回任务所在 Saga 中第一个失败的任务名，Saga 未失败或任务不属于 Saga 时返回空字符串
+func (ts *TaskScheduler) abortedBy(name string) string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	root, ok := ts.sagaOf[name]
+	if !ok {
+		return ""
+	}
+	return ts.aborted[root]
+}
+
+// compensate 对本次执行中失败的 Saga，逆序执行其中已成功步骤的补偿操作。
+// results 是本次执行按任务完成顺序追加的结果，因此逆序遍历即为补偿顺序。
+// 补偿操作串行执行，单个补偿失败不会中断其余补偿。
+func (ts *TaskScheduler) compensate(results []TaskResult) {
+	ts.mu.Lock()
+	aborted := len(ts.aborted) > 0
+	ts.mu.Unlock()
+	if !aborted {
+		return
+	}
+
+	fns := make(map[string]func() error, len(ts.tasks))
+	for _, t := range ts.tasks {
+		if t.Compensate != nil {
+			fns[t.Name] = t.Compensate
+		}
+	}
+
+	for i := len(results) - 1; i >= 0; i-- {
+		result := &results[i]
+		fn, ok := fns[result.Name]
+		// 缓存命中的任务本次没有真正执行，无需补偿；未失败的 Saga 保持原样
+		if !ok || result.Error != nil || result.CacheHit || result.Compensation != nil || ts.aborted[ts.sagaOf[result.Name]] == "" {
+			continue
+		}
+
+		startTime := time.Now()
+		err := fn()
+		result.Compensation = &CompensationResult{Duration: time.Since(startTime), Error: err}
+		if err != nil {
+			ts.logf("✗ 任务 '%s' 补偿失败：%v\n", result.Name, err)
+		} else {
+			ts.logf("↺ 任务 '%s' 已补偿\n", result.Name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package two_goroutine
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// compensations 记录补偿操作的执行顺序
+type compensations struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (c *compensations) step(name string, err error) (func() error, func() error) {
+	return func() error { return err }, func() error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.order = append(c.order, name)
+		return nil
+	}
+}
+
+func resultsByName(ts *TaskScheduler) map[string]TaskResult {
+	results := make(map[string]TaskResult)
+	for _, r := range ts.GetResults() {
+		results[r.Name] = r
+	}
+	return results
+}
+
+func TestSagaCompensatesInReverseOrder(t *testing.T) {
+	var c compensations
+	ts := newTestScheduler()
+	prev := []string(nil)
+	for _, name := range []string{"s1", "s2", "s3"} {
+		fn, undo := c.step(name, nil)
+		if err := ts.AddStep(name, fn, undo, prev...); err != nil {
+			t.Fatal(err)
+		}
+		prev = []string{name}
+	}
+	fail, undo := c.step("s4", errors.New("失败"))
+	ts.AddStep("s4", fail, undo, "s3")
+	ts.Execute()
+
+	if want := []string{"s3", "s2", "s1"}; !reflect.DeepEqual(c.order, want) {
+		t.Errorf("compensation order = %v, want %v", c.order, want)
+	}
+	if r := resultsByName(ts)["s4"]; r.Compensation != nil {
+		t.Error("failed step was compensated")
+	}
+}
+
+func TestSagaCompensatesOnlyFailedSaga(t *testing.T) {
+	var c compensations
+	ts := newTestScheduler()
+	a1, undoA1 := c.step("a1", nil)
+	a2, undoA2 := c.step("a2", errors.New("失败"))
+	b1, undoB1 := c.step("b1", nil)
+	b2, undoB2 := c.step("b2", nil)
+	ts.AddStep("a1", a1, undoA1)
+	ts.AddStep("a2", a2, undoA2, "a1")
+	ts.AddStep("b1", b1, undoB1)
+	ts.AddStep("b2", b2, undoB2, "b1")
+	// 不属于任何 Saga 的普通任务失败不会触发补偿
+	ts.AddTask("普通任务", func() error { return errors.New("失败") })
+	ts.Execute()
+
+	if want := []string{"a1"}; !reflect.DeepEqual(c.order, want) {
+		t.Errorf("compensated = %v, want %v", c.order, want)
+	}
+}
+
+func TestSagaCancelsPendingSteps(t *testing.T) {
+	var c compensations
+	ts := newTestScheduler()
+
+	// slow 开始后 fail 才失败，fail 失败后 slow 才完成，此时依赖 slow 的 later 还没有开始
+	started, failed := make(chan struct{}), make(chan struct{})
+	ts.Subscribe(func(e Event) {
+		switch {
+		case e.Kind == EventStarted && e.Name == "slow":
+			close(started)
+		case e.Kind == EventFailed && e.Name == "fail":
+			close(failed)
+		}
+	})
+
+	root, undoRoot := c.step("root", nil)
+	_, undoFail := c.step("fail", nil)
+	fail := func() error { <-started; return errors.New("失败") }
+	_, undoSlow := c.step("slow", nil)
+	laterRan := false
+	ts.AddStep("root", root, undoRoot)
+	ts.AddStep("fail", fail, undoFail, "root")
+	ts.AddStep("slow", func() error { <-failed; return nil }, undoSlow, "root")
+	ts.AddStep("later", func() error { laterRan = true; return nil }, nil, "slow")
+	ts.Execute()
+
+	if laterRan {
+		t.Error("step after failure was started")
+	}
+	results := resultsByName(ts)
+	if r := results["later"]; !errors.Is(r.Error, ErrSagaAborted) {
+		t.Errorf("later: error = %v, want ErrSagaAborted", r.Error)
+	}
+	if want := []string{"slow", "root"}; !reflect.DeepEqual(c.order, want) {
+		t.Errorf("compensation order = %v, want %v", c.order, want)
+	}
+}
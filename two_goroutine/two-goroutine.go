@@ -1,8 +1,14 @@
 package two_goroutine
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -42,22 +48,45 @@ func GetOne() {
 
 // Task 定义任务类型
 type Task struct { // 使用AI
-	Name string
-	Fn   func() error
+	Name    string
+	Fn      func() error
+	Retries int      // 失败后的重试次数，0 表示只执行一次
+	Deps    []string // 依赖的上游任务名，全部成功后才会执行
+
+	Compensate func() error // 补偿操作，其他任务失败时用来撤销本任务的效果，见 saga.go
+	CacheKey   string       // 结果缓存的键，相同键的任务复用结果，为空表示不缓存，见 cache.go
+
+	replayed *DeadLetter // 由 Replay 重新提交时为原死信：成功后才从死信存储中删除，再次失败时更新
 }
 
 // TaskResult 存储任务执行结果
 type TaskResult struct {
 	Name     string
 	Duration time.Duration
-	Error    error
+	Error    error   // 最后一次执行的错误
+	Attempts int     // 实际执行次数
+	Errors   []error // 每次失败的错误历史
+
+	Compensation *CompensationResult // 补偿操作的执行结果，未补偿时为 nil
+	CacheHit     bool                // 复用了缓存结果，此时 Duration 为 0
 }
 
 // TaskScheduler 任务调度器
 type TaskScheduler struct {
-	tasks   []Task
-	results []TaskResult
-	mu      sync.Mutex
+	tasks       []Task
+	results     []TaskResult
+	deadLetters DeadLetterStore          // 重试用尽后仍失败的任务存放处，nil 表示不记录
+	flows       map[string]flowSpec      // 数据流任务的输出类型，见 AddFlow
+	outputs     map[string]reflect.Value // 本次执行中各任务发布的输出
+	failed      map[string]bool          // 本次执行中失败（或被跳过）的任务
+	sagaOf      map[string]string        // 属于 Saga 的任务 → 所在 Saga 的根任务，见 saga.go
+	aborted     map[string]string        // 本次执行中已失败的 Saga 根任务 → 第一个失败的任务
+	cache       ResultCache              // 结果缓存，nil 表示不缓存
+	inflight    map[string]*cacheCall    // 正在执行的缓存键，用于合并相同键的并发执行
+	cacheMu     sync.Mutex               // 保护 inflight
+	handlers    []func(Event)            // 调度事件的订阅者，见 events.go
+	out         io.Writer                // 任务执行日志的输出，默认 os.Stdout
+	mu          sync.Mutex
 }
 
 // NewTaskScheduler 创建新的任务调度器
@@ -65,53 +94,206 @@ func NewTaskScheduler() *TaskScheduler {
 	return &TaskScheduler{
 		tasks:   []Task{},
 		results: []TaskResult{},
+		out:     os.Stdout,
 	}
 }
 
-// AddTask 添加任务到调度器
-func (ts *TaskScheduler) AddTask(name string, fn func() error) {
-	ts.tasks = append(ts.tasks, Task{Name: name, Fn: fn})
+// SetOutput 设置任务执行日志的输出，传入 io.Discard 可关闭日志（例如使用 Dashboard 时）
+func (ts *TaskScheduler) SetOutput(w io.Writer) {
+	ts.out = w
+}
+
+// logf 输出一行任务执行日志
+func (ts *TaskScheduler) logf(format string, args ...any) {
+	fmt.Fprintf(ts.out, format, args...)
+}
+
+// AddTask 添加任务到调度器，任务名必须唯一
+func (ts *TaskScheduler) AddTask(name string, fn func() error) error {
+	return ts.addTask(Task{Name: name, Fn: fn})
+}
+
+// AddTaskWithRetry 添加带重试次数的任务到调度器，任务名必须唯一
+func (ts *TaskScheduler) AddTaskWithRetry(name string, retries int, fn func() error) error {
+	return ts.addTask(Task{Name: name, Fn: fn, Retries: retries})
 }
 
-// Execute 并发执行所有任务
+// addTask 添加任务。任务名用于查找依赖、输出和缓存，重名的任务会互相覆盖，因此直接拒绝
+func (ts *TaskScheduler) addTask(t Task) error {
+	if ts.hasTask(t.Name) {
+		return fmt.Errorf("任务 '%s' 已存在", t.Name)
+	}
+	ts.tasks = append(ts.tasks, t)
+	return nil
+}
+
+// SetDeadLetterStore 设置死信存储，重试用尽后仍失败的任务会写入其中
+func (ts *TaskScheduler) SetDeadLetterStore(store DeadLetterStore) {
+	ts.deadLetters = store
+}
+
+// Execute 并发执行所有任务，有依赖的任务会等上游全部完成后再执行
 func (ts *TaskScheduler) Execute() {
 	var wg sync.WaitGroup
 
-	// 为每个任务启动一个协程
+	ts.mu.Lock()
+	ts.outputs = make(map[string]reflect.Value)
+	ts.failed = make(map[string]bool)
+	ts.sagaOf = ts.sagas()
+	ts.aborted = make(map[string]string)
+	start := len(ts.results)
+	ts.mu.Unlock()
+
+	// 每个任务完成时关闭自己的 done 通道，下游任务通过它等待
+	done := make([]chan struct{}, len(ts.tasks))
+	index := make(map[string]int, len(ts.tasks))
+	for i, task := range ts.tasks {
+		done[i] = make(chan struct{})
+		index[task.Name] = i
+	}
+
 	for _, task := range ts.tasks {
+		ts.emit(Event{Kind: EventQueued, Name: task.Name})
+	}
+
+	// 为每个任务启动一个协程
+	for i, task := range ts.tasks {
 		wg.Add(1)
-		go func(t Task) {
+		go func(t Task, finished chan struct{}) {
 			defer wg.Done()
+			defer close(finished)
 
-			// 记录开始时间
-			startTime := time.Now()
-
-			// 执行任务
-			err := t.Fn()
-
-			// 计算执行时间
-			duration := time.Since(startTime)
-
-			// 将结果存储到调度器中（需要使用锁保护）
-			ts.mu.Lock()
-			ts.results = append(ts.results, TaskResult{
-				Name:     t.Name,
-				Duration: duration,
-				Error:    err,
-			})
-			ts.mu.Unlock()
-
-			// 打印任务执行信息
-			if err != nil {
-				fmt.Printf("✗ 任务 '%s' 执行失败，耗时 %v，错误：%v\n", t.Name, duration, err)
-			} else {
-				fmt.Printf("✓ 任务 '%s' 执行完成，耗时 %v\n", t.Name, duration)
+			// 不存在的依赖（以及依赖自身）没有可等待的通道，由 run 报告为失败
+			for _, dep := range t.Deps {
+				if i, ok := index[dep]; ok && dep != t.Name {
+					<-done[i]
+				}
 			}
-		}(task)
+			ts.run(t)
+		}(task, done[i])
 	}
 
 	// 等待所有任务完成
 	wg.Wait()
+
+	// 有 Saga 失败时，按完成顺序的逆序执行该 Saga 中已完成步骤的补偿操作
+	ts.compensate(ts.results[start:])
+}
+
+// run 执行单个任务并记录结果
+func (ts *TaskScheduler) run(t Task) {
+	// Deps 是导出字段，可能绕过 AddTaskAfter、AddFlow 的检查，执行前再校验一次
+	if err := ts.checkDeps(t); err != nil {
+		ts.record(TaskResult{Name: t.Name, Error: err})
+		ts.emit(Event{Kind: EventFailed, Name: t.Name, Err: err})
+		ts.logf("✗ 任务 '%s' 无法执行：%v\n", t.Name, err)
+		return
+	}
+	// 上游失败时跳过，不重试也不进入死信
+	if dep := ts.failedDep(t); dep != "" {
+		err := fmt.Errorf("%w：%s", ErrUpstreamFailed, dep)
+		ts.record(TaskResult{Name: t.Name, Error: err})
+		ts.emit(Event{Kind: EventSkipped, Name: t.Name, Err: err})
+		ts.logf("- 任务 '%s' 已跳过：%v\n", t.Name, err)
+		return
+	}
+	// 所在 Saga 已有步骤失败，尚未开始的步骤不再执行
+	if failed := ts.abortedBy(t.Name); failed != "" {
+		err := fmt.Errorf("%w：%s", ErrSagaAborted, failed)
+		ts.record(TaskResult{Name: t.Name, Error: err})
+		ts.emit(Event{Kind: EventSkipped, Name: t.Name, Err: err})
+		ts.logf("- 任务 '%s' 已取消：%v\n", t.Name, err)
+		return
+	}
+
+	if t.CacheKey != "" && ts.cache != nil {
+		ts.runCached(t)
+		return
+	}
+	ts.execute(t)
+}
+
+// execute 执行任务并记录结果，返回最后一次执行的错误
+func (ts *TaskScheduler) execute(t Task) error {
+	// 执行任务（失败时按 Retries 重试），并计算执行时间
+	ts.emit(Event{Kind: EventStarted, Name: t.Name})
+	startTime := time.Now()
+	var errs []error
+	var err error
+	attempts := 0
+	for attempts <= t.Retries {
+		attempts++
+		if err = t.Fn(); err == nil {
+			break
+		}
+		errs = append(errs, err)
+	}
+	duration := time.Since(startTime)
+
+	// 将结果存储到调度器中
+	ts.record(TaskResult{
+		Name:     t.Name,
+		Duration: duration,
+		Error:    err,
+		Attempts: attempts,
+		Errors:   errs,
+	})
+	if err != nil {
+		ts.emit(Event{Kind: EventFailed, Name: t.Name, Duration: duration, Err: err})
+	} else {
+		ts.emit(Event{Kind: EventSucceeded, Name: t.Name, Duration: duration})
+	}
+
+	// 重试用尽仍失败，写入死信存储；重放的任务再次失败时更新原有死信，让运维看到重放也失败了。
+	// 重放的任务成功后才删除它的死信，重放中途崩溃不会丢失死信。
+	switch {
+	case ts.deadLetters == nil:
+	case err != nil && t.replayed == nil:
+		if dlErr := ts.deadLetters.Put(NewDeadLetter(t, errs)); dlErr != nil {
+			ts.logf("✗ 任务 '%s' 写入死信失败：%v\n", t.Name, dlErr)
+		}
+	case err != nil:
+		if dlErr := ts.deadLetters.Replace(t.replayed.retried(errs)); dlErr != nil {
+			ts.logf("✗ 任务 '%s' 更新死信失败：%v\n", t.Name, dlErr)
+		}
+	case t.replayed != nil:
+		if dlErr := ts.deadLetters.Remove(t.Name); dlErr != nil {
+			ts.logf("✗ 任务 '%s' 删除死信失败：%v\n", t.Name, dlErr)
+		}
+	}
+
+	// 打印任务执行信息
+	if err != nil {
+		ts.logf("✗ 任务 '%s' 执行失败，耗时 %v，错误：%v\n", t.Name, duration, err)
+	} else {
+		ts.logf("✓ 任务 '%s' 执行完成，耗时 %v\n", t.Name, duration)
+	}
+	return err
+}
+
+// record 保存任务结果（需要使用锁保护）
+func (ts *TaskScheduler) record(result TaskResult) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.results = append(ts.results, result)
+	if result.Error != nil {
+		ts.failed[result.Name] = true
+		if root, ok := ts.sagaOf[result.Name]; ok && ts.aborted[root] == "" {
+			ts.aborted[root] = result.Name
+		}
+	}
+}
+
+// failedDep 返回第一个失败的上游任务名，没有则返回空字符串
+func (ts *TaskScheduler) failedDep(t Task) string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, dep := range t.Deps {
+		if ts.failed[dep] {
+			return dep
+		}
+	}
+	return ""
 }
 
 // GetResults 获取所有任务的执行结果
@@ -126,44 +308,66 @@ func (ts *TaskScheduler) PrintSummary() {
 	for _, result := range ts.results {
 		totalDuration += result.Duration
 		status := "成功"
-		if result.Error != nil {
+		if skipped(result.Error) {
+			status = "跳过"
+		} else if result.Error != nil {
 			status = "失败"
 		}
-		fmt.Printf("任务: %-15s | 状态: %-4s | 耗时: %10v\n", result.Name, status, result.Duration)
+		fmt.Printf("任务: %-15s | 状态: %-4s | 耗时: %10v", result.Name, status, result.Duration)
+		if c := result.Compensation; c != nil {
+			compensation := "成功"
+			if c.Error != nil {
+				compensation = "失败"
+			}
+			fmt.Printf(" | 补偿: %s", compensation)
+		}
+		if result.CacheHit {
+			fmt.Print(" | 缓存命中")
+		}
+		fmt.Println()
 	}
 	fmt.Printf("总耗时: %v\n", totalDuration)
 	fmt.Println("==================================")
 }
 
+// DemoTasks 返回 GetTwo 使用的示例任务
+func DemoTasks() []Task {
+	return []Task{
+		{Name: "任务1", Fn: func() error {
+			time.Sleep(1 * time.Second)
+			fmt.Println("  → 任务1 的具体工作内容")
+			return nil
+		}},
+		{Name: "任务2", Fn: func() error {
+			time.Sleep(500 * time.Millisecond)
+			fmt.Println("  → 任务2 的具体工作内容")
+			return nil
+		}},
+		{Name: "任务3", Fn: func() error {
+			time.Sleep(800 * time.Millisecond)
+			fmt.Println("  → 任务3 的具体工作内容")
+			return nil
+		}},
+		{Name: "任务4", Fn: func() error {
+			time.Sleep(300 * time.Millisecond)
+			fmt.Println("  → 任务4 的具体工作内容")
+			return nil
+		}},
+	}
+}
+
 // GetTwo 演示任务调度器的使用
 func GetTwo() {
 	// 创建任务调度器
 	scheduler := NewTaskScheduler()
 
 	// 添加示例任务
-	scheduler.AddTask("任务1", func() error {
-		time.Sleep(1 * time.Second)
-		fmt.Println("  → 任务1 的具体工作内容")
-		return nil
-	})
-
-	scheduler.AddTask("任务2", func() error {
-		time.Sleep(500 * time.Millisecond)
-		fmt.Println("  → 任务2 的具体工作内容")
-		return nil
-	})
-
-	scheduler.AddTask("任务3", func() error {
-		time.Sleep(800 * time.Millisecond)
-		fmt.Println("  → 任务3 的具体工作内容")
-		return nil
-	})
-
-	scheduler.AddTask("任务4", func() error {
-		time.Sleep(300 * time.Millisecond)
-		fmt.Println("  → 任务4 的具体工作内容")
-		return nil
-	})
+	for _, t := range DemoTasks() {
+		if err := scheduler.AddTask(t.Name, t.Fn); err != nil {
+			fmt.Println("添加任务失败：", err)
+			return
+		}
+	}
 
 	// 执行所有任务（并发）
 	fmt.Println("开始执行任务...")
@@ -172,3 +376,200 @@ func GetTwo() {
 	// 打印执行摘要
 	scheduler.PrintSummary()
 }
+
+// inventoryDown 模拟库存服务故障，GetThree 运行期间为 true
+var inventoryDown atomic.Bool
+
+// syncInventory 同步库存，库存服务故障时失败
+func syncInventory() error {
+	if inventoryDown.Load() {
+		return errors.New("库存服务不可用")
+	}
+	fmt.Println("  → 库存同步完成")
+	return nil
+}
+
+// ReplayTasks 返回死信重放时按任务名查找函数的注册表：DemoTasks 加上 GetThree 的同步库存任务
+func ReplayTasks() []Task {
+	return append(DemoTasks(), Task{Name: "同步库存", Fn: syncInventory, Retries: 2})
+}
+
+// GetThree 演示死信队列：库存服务故障期间任务重试用尽，写入 path 指定的 JSON-lines 死信文件。
+// 服务恢复后可以用 go run . replay -file path 重放，重放成功的死信会从文件中删除。
+// path 为空时写到临时目录，演示结束后删除，不会在当前目录留下文件。
+func GetThree(path string) {
+	inventoryDown.Store(true)
+	defer inventoryDown.Store(false)
+
+	keep := path != ""
+	if !keep {
+		dir, err := os.MkdirTemp("", "deadletter")
+		if err != nil {
+			fmt.Println("创建临时目录失败：", err)
+			return
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "deadletter.jsonl")
+	}
+
+	store := NewFileDeadLetterStore(path)
+	scheduler := NewTaskScheduler()
+	scheduler.SetDeadLetterStore(store)
+	if err := scheduler.AddTaskWithRetry("同步库存", 2, syncInventory); err != nil {
+		fmt.Println("添加任务失败：", err)
+		return
+	}
+	scheduler.Execute()
+
+	letters, err := store.List()
+	if err != nil {
+		fmt.Println("读取死信失败：", err)
+		return
+	}
+	for _, dl := range letters {
+		fmt.Printf("死信：%s，共尝试 %d 次，错误历史：%v\n", dl.Name, dl.Attempts, dl.Errors)
+	}
+	if keep {
+		fmt.Printf("死信已写入 %s，可运行 go run . replay -file %s 重放\n", path, path)
+	} else {
+		fmt.Println("死信写在临时文件中，演示结束后删除；运行 go run . -deadletter deadletter.jsonl 可保留死信文件用于重放")
+	}
+}
+
+// GetFour 演示任务依赖与数据流：抽取 → 转换 → 加载 的小型 ETL
+func GetFour() {
+	scheduler := NewTaskScheduler()
+
+	steps := []struct {
+		name string
+		fn   any
+		deps []string
+	}{
+		{"抽取订单", func() ([]int, error) {
+			return []int{120, 80, 45}, nil
+		}, nil},
+		{"抽取退款", func() ([]int, error) {
+			return []int{30}, nil
+		}, nil},
+		{"计算净额", func(orders, refunds []int) (int, error) {
+			total := 0
+			for _, v := range orders {
+				total += v
+			}
+			for _, v := range refunds {
+				total -= v
+			}
+			return total, nil
+		}, []string{"抽取订单", "抽取退款"}},
+		{"写入报表", func(net int) error {
+			fmt.Println("  → 净额：", net)
+			return nil
+		}, []string{"计算净额"}},
+	}
+	for _, step := range steps {
+		if err := scheduler.AddFlow(step.name, step.fn, step.deps...); err != nil {
+			fmt.Println("构建任务图失败：", err)
+			return
+		}
+	}
+
+	// 类型不匹配在构建任务图时就会报错
+	err := scheduler.AddFlow("错误示例", func(net string) error { return nil }, "计算净额")
+	fmt.Println("类型检查：", err)
+
+	scheduler.Execute()
+	scheduler.PrintSummary()
+}
+
+// GetFive 演示 Saga：下单流程中任意一步失败，已完成的步骤逆序补偿
+func GetFive() {
+	scheduler := NewTaskScheduler()
+
+	step := func(name, undo string) (func() error, func() error) {
+		return func() error {
+				fmt.Println("  →", name)
+				return nil
+			}, func() error {
+				fmt.Println("  ←", undo)
+				return nil
+			}
+	}
+
+	reserve, release := step("锁定库存", "释放库存")
+	charge, refund := step("扣款", "退款")
+	steps := []struct {
+		name           string
+		fn, compensate func() error
+		deps           []string
+	}{
+		{"锁定库存", reserve, release, nil},
+		{"扣款", charge, refund, []string{"锁定库存"}},
+		{"发货", func() error {
+			return errors.New("物流服务不可用")
+		}, nil, []string{"扣款"}},
+	}
+	for _, s := range steps {
+		if err := scheduler.AddStep(s.name, s.fn, s.compensate, s.deps...); err != nil {
+			fmt.Println("构建任务图失败：", err)
+			return
+		}
+	}
+
+	scheduler.Execute()
+	scheduler.PrintSummary()
+}
+
+// GetSix 演示结果缓存：相同缓存键的任务只执行一次，再次执行时直接复用结果
+func GetSix() {
+	scheduler := NewTaskScheduler()
+	scheduler.SetResultCache(NewMemoryResultCache(time.Minute, 100))
+
+	for _, name := range []string{"汇率查询A", "汇率查询B"} {
+		err := scheduler.AddFlow(name, func() (float64, error) {
+			time.Sleep(200 * time.Millisecond)
+			fmt.Println("  → 请求汇率服务")
+			return 7.1, nil
+		})
+		if err == nil {
+			err = scheduler.SetCacheKey(name, "rate:USD/CNY")
+		}
+		if err != nil {
+			fmt.Println("构建任务图失败：", err)
+			return
+		}
+	}
+
+	fmt.Println("第一次执行（两个任务键相同，只请求一次）...")
+	scheduler.Execute()
+	fmt.Println("第二次执行（全部命中缓存）...")
+	scheduler.Execute()
+	scheduler.PrintSummary()
+}
+
+// GetSeven 演示实时进度面板：任务日志关闭，由面板展示执行进度
+func GetSeven() {
+	scheduler := NewTaskScheduler()
+	scheduler.SetOutput(io.Discard)
+
+	for i := 1; i <= 12; i++ {
+		d := time.Duration(i%4+1) * 150 * time.Millisecond
+		name := fmt.Sprintf("任务%d", i)
+		err := scheduler.AddTask(name, func() error {
+			time.Sleep(d)
+			if i%5 == 0 {
+				return errors.New("模拟失败")
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println("添加任务失败：", err)
+			return
+		}
+	}
+
+	dashboard := NewDashboard(os.Stdout, 200*time.Millisecond)
+	dashboard.Attach(scheduler)
+	dashboard.Start()
+	scheduler.Execute()
+	dashboard.Stop()
+}